@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+)
+
+const apiKeyPrefix = "sk_"
+
+type Principal struct {
+	Name   string
+	Method string
+	Roles  []Role
+	// CertificateSubject is the verified TLS client certificate subject, if
+	// the connection presented one.
+	CertificateSubject string
+}
+
+type principalKey struct{}
+
+func ParseScope(scope string) (Scope, error) {
+	switch Scope(scope) {
+	case ScopeRead, ScopeWrite:
+		return Scope(scope), nil
+	}
+	return "", fmt.Errorf("scope must be %q or %q", ScopeRead, ScopeWrite)
+}
+
+// RoleForScope maps an API key scope onto the role model shared with bearer
+// tokens. Keys never grant admin.
+func RoleForScope(scope Scope) Role {
+	if scope == ScopeWrite {
+		return RoleEditor
+	}
+	return RoleReader
+}
+
+// HasRole reports whether any of the principal roles is at least as strong
+// as the required one.
+func (p Principal) HasRole(required Role) bool {
+	for _, role := range p.Roles {
+		if role.rank() >= required.rank() {
+			return true
+		}
+	}
+	return false
+}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// GenerateApiKey returns a new random key. Only its hash is ever stored, so
+// the raw value has to be handed to the caller right away.
+func GenerateApiKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
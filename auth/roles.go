@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleReader:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+func ParseRole(role string) (Role, error) {
+	parsed := Role(strings.ToLower(strings.TrimSpace(role)))
+	if parsed.rank() == 0 {
+		return "", fmt.Errorf("role must be %q, %q or %q", RoleReader, RoleEditor, RoleAdmin)
+	}
+	return parsed, nil
+}
+
+// RoleMapper turns the values of a token claim into roles. Claim values
+// without an entry in Mapping are matched against the role names directly.
+type RoleMapper struct {
+	Claim   string
+	Mapping map[string]Role
+}
+
+func (m RoleMapper) Roles(claims map[string]any) []Role {
+	var roles []Role
+	for _, value := range claimValues(claims, m.Claim) {
+		if role, ok := m.Mapping[value]; ok {
+			roles = append(roles, role)
+			continue
+		}
+		if role, err := ParseRole(value); err == nil {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// claimValues reads a string or list-of-strings claim. Nested claims such as
+// Keycloak's realm_access.roles are addressed with a dotted path.
+func claimValues(claims map[string]any, path string) []string {
+	var current any = claims
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = object[part]
+	}
+
+	switch value := current.(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
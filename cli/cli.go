@@ -0,0 +1,353 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-mongo-app/auth"
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+)
+
+const apiKeysCollectionName string = "api_keys"
+const swiftCodesCollectionName string = "swift_codes"
+const bankCodesCollectionName string = "bank_codes"
+
+const usage = `usage:
+  apikey create -name NAME -scope read|write
+  apikey revoke -name NAME
+  apikey list
+  sync -file FILE [-format FORMAT] [-apply] [-max-removal RATIO]
+  export [-format FORMAT] [-file FILE]
+  preview -file FILE [-format FORMAT] [-rows N]
+  bankcodes -file FILE
+  gazetteer -file FILE
+  report data-quality [-samples N] [-json]
+  report orphan-branches [-reconcile synthesize|reject]`
+
+// Run executes a single command given on the command line. The mongo client
+// has to be registered with services.New before calling it.
+func Run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", usage)
+	}
+
+	switch args[0] {
+	case "apikey":
+		return runApiKey(args[1:])
+	case "sync":
+		return runSync(args[1:])
+	case "export":
+		return runExport(args[1:])
+	case "preview":
+		return runPreview(args[1:])
+	case "bankcodes":
+		return runBankCodes(args[1:])
+	case "gazetteer":
+		return runGazetteer(args[1:])
+	case "report":
+		return runReport(args[1:])
+	}
+	return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+}
+
+func runApiKey(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing apikey subcommand\n%s", usage)
+	}
+
+	var apiKey services.ApiKeys
+	flags := flag.NewFlagSet("apikey "+args[0], flag.ContinueOnError)
+	name := flags.String("name", "", "name identifying the key owner")
+	scope := flags.String("scope", string(auth.ScopeRead), "key scope, read or write")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		parsedScope, err := auth.ParseScope(*scope)
+		if err != nil {
+			return err
+		}
+		rawKey, err := apiKey.CreateApiKey(*name, parsedScope, apiKeysCollectionName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created %s key %q. Store it now, it won't be shown again:\n%s\n", parsedScope, *name, rawKey)
+		return nil
+	case "revoke":
+		if err := apiKey.RevokeApiKey(*name, apiKeysCollectionName); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked key %q\n", *name)
+		return nil
+	case "list":
+		apiKeys, err := apiKey.GetAllApiKeys(apiKeysCollectionName)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPREFIX\tSCOPE\tCREATED\tREVOKED")
+		for _, key := range apiKeys {
+			revoked := "-"
+			if key.RevokedAt != nil {
+				revoked = key.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.Name, key.KeyPrefix, key.Scope, key.CreatedAt.Format(time.RFC3339), revoked)
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown apikey subcommand %q\n%s", args[0], usage)
+}
+
+// runSync prints the difference between a directory file and the stored
+// swift codes and applies it when asked to.
+func runSync(args []string) error {
+	var swiftCodes services.SwiftCodes
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	file := flags.String("file", "", "directory file to synchronize with")
+	formatName := flags.String("format", "", "format or CSV profile of the file, detected when empty")
+	apply := flags.Bool("apply", false, "apply the changes instead of only listing them")
+	maxRemoval := flags.Float64("max-removal", 0.1, "largest fraction of the current swift codes the sync may remove")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("missing -file\n%s", usage)
+	}
+
+	incoming, err := parser.ReadDirectoryFile(*file, *formatName)
+	if err != nil {
+		return err
+	}
+	plan, err := swiftCodes.PlanSync(incoming, swiftCodesCollectionName)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, addition := range plan.Additions {
+		fmt.Fprintf(w, "+\t%s\t%s\n", addition.SwiftCode, addition.BankName)
+	}
+	for _, change := range plan.Changes {
+		fmt.Fprintf(w, "~\t%s\t%s\n", change.After.SwiftCode, strings.Join(change.Fields, ", "))
+	}
+	for _, removal := range plan.Removals {
+		fmt.Fprintf(w, "-\t%s\t%s\n", removal.SwiftCode, removal.BankName)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d to add, %d to change, %d to remove (%.1f%% of %d), %d unchanged\n",
+		len(plan.Additions), len(plan.Changes), len(plan.Removals), plan.RemovalRatio()*100, plan.Current, plan.Unchanged)
+
+	if !*apply || plan.IsEmpty() {
+		return nil
+	}
+	ctx := services.WithAuditContext(context.Background(), services.AuditContext{Actor: "system:sync"})
+	if err = swiftCodes.ApplySync(ctx, plan, *maxRemoval, swiftCodesCollectionName); err != nil {
+		return err
+	}
+	fmt.Println("Applied")
+	return nil
+}
+
+// runExport writes the current swift codes in one of the directory file
+// formats, to standard output unless a file is given.
+func runExport(args []string) error {
+	var swiftCodes services.SwiftCodes
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := flags.String("format", "csv", "one of "+strings.Join(parser.FormatNames(), ", "))
+	file := flags.String("file", "", "file to write, standard output when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := parser.FormatByName(*formatName)
+	if err != nil {
+		return err
+	}
+	current, err := swiftCodes.GetAllSwiftCodes(swiftCodesCollectionName)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return format.Write(os.Stdout, current)
+	}
+	out, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+	if err = format.Write(out, current); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// runPreview shows how the first rows of a file are parsed, so a format or
+// CSV profile can be checked before importing with it.
+func runPreview(args []string) error {
+	flags := flag.NewFlagSet("preview", flag.ContinueOnError)
+	file := flags.String("file", "", "directory file to preview")
+	formatName := flags.String("format", "", "one of "+strings.Join(parser.FormatNames(), ", ")+", detected when empty")
+	limit := flags.Int("rows", 10, "number of rows to show")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("missing -file\n%s", usage)
+	}
+
+	in, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	format, rows, err := parser.ReadRows(in, *formatName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Format %s, %d rows\n", format.Name(), len(rows))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tSWIFT CODE\tCOUNTRY\tNAME\tADDRESS\tTOWN\tCOUNTRY NAME\tTIME ZONE\tERROR")
+	for i, row := range rows {
+		if i == *limit {
+			break
+		}
+		problem := "-"
+		if _, err := row.ToSwiftCode(); err != nil {
+			problem = err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", row.Line, row.SwiftCode, row.CountryISO2Code,
+			row.BankName, row.Address, row.TownName, row.CountryName, row.TimeZone, problem)
+	}
+	return w.Flush()
+}
+
+// runBankCodes imports a bank code table used to resolve IBANs and national
+// bank codes.
+func runBankCodes(args []string) error {
+	flags := flag.NewFlagSet("bankcodes", flag.ContinueOnError)
+	file := flags.String("file", "", "CSV file with COUNTRY ISO2 CODE, BANK CODE, SWIFT CODE and optional SCHEME columns")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("missing -file\n%s", usage)
+	}
+
+	imported, err := parser.ImportBankCodesFile(context.Background(), *file, bankCodesCollectionName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d bank codes\n", imported)
+	return nil
+}
+
+// runGazetteer imports a gazetteer and locates the swift codes with it.
+func runGazetteer(args []string) error {
+	flags := flag.NewFlagSet("gazetteer", flag.ContinueOnError)
+	file := flags.String("file", "", "CSV file with COUNTRY ISO2 CODE, TOWN NAME, LATITUDE and LONGITUDE columns")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("missing -file\n%s", usage)
+	}
+
+	imported, err := parser.ImportGazetteerFile(context.Background(), *file, swiftCodesCollectionName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d places\n", imported)
+	return nil
+}
+
+// runReport prints a report over the stored swift codes.
+func runReport(args []string) error {
+	if len(args) > 0 && args[0] == "orphan-branches" {
+		return runOrphanReport(args[1:])
+	}
+	if len(args) == 0 || args[0] != "data-quality" {
+		return fmt.Errorf("missing or unknown report\n%s", usage)
+	}
+
+	var swiftCodes services.SwiftCodes
+	flags := flag.NewFlagSet("report data-quality", flag.ContinueOnError)
+	samples := flags.Int("samples", 10, "number of sample records per check")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	report, err := swiftCodes.GetDataQualityReport(*samples, swiftCodesCollectionName)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("%d swift codes checked\n", report.TotalCodes)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", check.Name, check.Count, check.Description)
+		for _, sample := range check.Samples {
+			fmt.Fprintf(w, "\t\t  %s\t%s\t%q\t%s\n", sample.SwiftCode, sample.Field, sample.Value, sample.Detail)
+		}
+	}
+	return w.Flush()
+}
+
+// runOrphanReport lists branches without a headquarter and placeholder
+// headquarters, and resolves the orphans when asked to.
+func runOrphanReport(args []string) error {
+	var swiftCodes services.SwiftCodes
+	flags := flag.NewFlagSet("report orphan-branches", flag.ContinueOnError)
+	reconcile := flags.String("reconcile", "", "synthesize placeholder headquarters or reject, i.e. delete, the orphans")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *reconcile != "" {
+		policy, err := services.ParseOrphanPolicy(*reconcile)
+		if err != nil {
+			return err
+		}
+		ctx := services.WithAuditContext(context.Background(), services.AuditContext{Actor: "system:reconcile"})
+		changed, err := swiftCodes.ReconcileOrphanBranches(ctx, policy, swiftCodesCollectionName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reconciled with %s, %d swift codes changed\n", policy, changed)
+	}
+
+	report, err := swiftCodes.GetOrphanReport(swiftCodesCollectionName)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, branch := range report.Branches {
+		fmt.Fprintf(w, "orphan\t%s\t%s\t%s\n", branch.SwiftCode, branch.CountryISO2Code, branch.BankName)
+	}
+	for _, placeholder := range report.Placeholders {
+		fmt.Fprintf(w, "placeholder\t%s\t%s\t%s\n", placeholder.SwiftCode, placeholder.CountryISO2Code, placeholder.BankName)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d branches without headquarter, %d placeholder headquarters\n", len(report.Branches), len(report.Placeholders))
+	return nil
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	MongoURI        string
+	Server          Server
+	Auth            Auth
+	RateLimit       RateLimit
+	CORS            CORS
+	SecurityHeaders SecurityHeaders
+	SoftDelete      SoftDelete
+	Imports         Imports
+	Watch           Watch
+}
+
+// Server serves plain HTTP unless both TLSCertFile and TLSKeyFile are set.
+// ClientCAFile turns on mutual TLS; ClientAuth is "require" or "optional".
+type Server struct {
+	Addr           string
+	TLSCertFile    string
+	TLSKeyFile     string
+	ClientCAFile   string
+	ClientAuth     string
+	ReloadInterval time.Duration
+}
+
+type Auth struct {
+	Enabled      bool
+	ProtectReads bool
+	JWT          JWT
+	// MTLSRoleMapping maps client certificate common names to roles.
+	MTLSRoleMapping map[string]string
+}
+
+// JWT bearer tokens are accepted only when JWKS points at a key set file or URL.
+type JWT struct {
+	JWKS        string
+	JWKSRefresh time.Duration
+	Issuer      string
+	Audience    string
+	RolesClaim  string
+	RoleMapping map[string]string
+	Leeway      time.Duration
+}
+
+// RateLimit describes token buckets kept per API key, token subject or
+// client IP. Routes maps "METHOD /pattern" to its own limit, e.g.
+// "GET /v1/swift-codes".
+type RateLimit struct {
+	Enabled      bool
+	Default      RouteLimit
+	Routes       map[string]RouteLimit
+	TrustProxy   bool
+	MaxBodyBytes int64
+}
+
+type RouteLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// CORS is disabled when AllowedOrigins is empty.
+type CORS struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+type SecurityHeaders struct {
+	Enabled               bool
+	HSTSMaxAge            int
+	HSTSIncludeSubdomains bool
+	FrameOptions          string
+}
+
+// SoftDelete keeps deleted codes restorable for Retention. Zero retention
+// turns purging off.
+type SoftDelete struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// Imports limits files uploaded to the import job API. ProfilesFile is a
+// JSON file with CSV profiles added to the built-in ones. OrphanBranchPolicy
+// is "flag", "reject" or "synthesize".
+type Imports struct {
+	MaxBytes           int64
+	ProfilesFile       string
+	OrphanBranchPolicy string
+}
+
+// Watch imports files dropped into Dir; an empty Dir turns it off.
+type Watch struct {
+	Dir      string
+	Interval time.Duration
+	Settle   time.Duration
+	Format   string
+}
+
+// Load reads the configuration from the environment and rejects settings
+// the app can't run with.
+func Load(isTested bool) (Config, error) {
+	if isTested {
+		godotenv.Load("../.env")
+	} else {
+		godotenv.Load()
+	}
+
+	cfg := Config{
+		MongoURI: getEnv("MONGO_URI", "mongodb://mongodb:27017"),
+		Server: Server{
+			Addr:           getEnv("HTTP_ADDR", ":8080"),
+			TLSCertFile:    getEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:     getEnv("TLS_KEY_FILE", ""),
+			ClientCAFile:   getEnv("TLS_CLIENT_CA_FILE", ""),
+			ClientAuth:     getEnv("TLS_CLIENT_AUTH", "require"),
+			ReloadInterval: getEnvDuration("TLS_RELOAD_INTERVAL", time.Minute),
+		},
+		Auth: Auth{
+			Enabled:      getEnvBool("API_AUTH_ENABLED", true),
+			ProtectReads: getEnvBool("API_AUTH_PROTECT_READS", false),
+			JWT: JWT{
+				JWKS:        getEnv("JWT_JWKS", ""),
+				JWKSRefresh: getEnvDuration("JWT_JWKS_REFRESH", time.Hour),
+				Issuer:      getEnv("JWT_ISSUER", ""),
+				Audience:    getEnv("JWT_AUDIENCE", ""),
+				RolesClaim:  getEnv("JWT_ROLES_CLAIM", "roles"),
+				RoleMapping: getEnvMap("JWT_ROLE_MAPPING"),
+				Leeway:      getEnvDuration("JWT_LEEWAY", 30*time.Second),
+			},
+			MTLSRoleMapping: getEnvMap("MTLS_ROLE_MAPPING"),
+		},
+		RateLimit: RateLimit{
+			Enabled: getEnvBool("RATE_LIMIT_ENABLED", true),
+			Default: RouteLimit{
+				RequestsPerSecond: getEnvFloat("RATE_LIMIT_RPS", 10),
+				Burst:             getEnvInt("RATE_LIMIT_BURST", 20),
+			},
+			Routes:       getEnvRouteLimits("RATE_LIMIT_ROUTES"),
+			TrustProxy:   getEnvBool("RATE_LIMIT_TRUST_PROXY", false),
+			MaxBodyBytes: int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+		},
+		CORS: CORS{
+			AllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS", nil),
+			AllowedMethods:   getEnvList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+			AllowedHeaders:   getEnvList("CORS_ALLOWED_HEADERS", []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key"}),
+			ExposedHeaders:   getEnvList("CORS_EXPOSED_HEADERS", []string{"Link", "Retry-After", "X-Request-Id"}),
+			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           getEnvInt("CORS_MAX_AGE", 300),
+		},
+		SecurityHeaders: SecurityHeaders{
+			Enabled:               getEnvBool("SECURITY_HEADERS_ENABLED", false),
+			HSTSMaxAge:            getEnvInt("HSTS_MAX_AGE", 31536000),
+			HSTSIncludeSubdomains: getEnvBool("HSTS_INCLUDE_SUBDOMAINS", false),
+			FrameOptions:          getEnv("FRAME_OPTIONS", "DENY"),
+		},
+		SoftDelete: SoftDelete{
+			Retention:     getEnvDuration("SOFT_DELETE_RETENTION", 30*24*time.Hour),
+			PurgeInterval: getEnvDuration("SOFT_DELETE_PURGE_INTERVAL", time.Hour),
+		},
+		Imports: Imports{
+			MaxBytes:           int64(getEnvInt("IMPORT_MAX_BYTES", 64<<20)),
+			ProfilesFile:       getEnv("IMPORT_PROFILES_FILE", ""),
+			OrphanBranchPolicy: getEnv("ORPHAN_BRANCH_POLICY", "flag"),
+		},
+		Watch: Watch{
+			Dir:      getEnv("WATCH_DIR", ""),
+			Interval: getEnvDuration("WATCH_INTERVAL", 30*time.Second),
+			Settle:   getEnvDuration("WATCH_SETTLE", 10*time.Second),
+			Format:   getEnv("WATCH_FORMAT", ""),
+		},
+	}
+	if err := cfg.Watch.validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func (w Watch) validate() error {
+	if w.Dir != "" && w.Interval <= 0 {
+		return fmt.Errorf("WATCH_INTERVAL must be positive, got %s", w.Interval)
+	}
+	return nil
+}
+
+func getEnv(key string, fallback string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return strings.TrimSpace(value)
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, strconv.FormatBool(fallback)))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, fallback.String()))
+	if err != nil {
+		log.Printf("invalid duration in %s, using %s", key, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvMap parses "key=value,key2=value2" lists.
+func getEnvMap(key string) map[string]string {
+	values := map[string]string{}
+	for _, pair := range getEnvList(key, nil) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			log.Printf("ignoring malformed entry %q in %s", pair, key)
+			continue
+		}
+		values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return values
+}
+
+func getEnvList(key string, fallback []string) []string {
+	value := getEnv(key, "")
+	if value == "" {
+		return fallback
+	}
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(fallback)))
+	if err != nil {
+		log.Printf("invalid number in %s, using %d", key, fallback)
+		return fallback
+	}
+	return value
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, strconv.FormatFloat(fallback, 'f', -1, 64)), 64)
+	if err != nil {
+		log.Printf("invalid number in %s, using %g", key, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvRouteLimits parses "GET /v1/swift-codes=1:5,POST /v1/swift-codes=0.5:2"
+// where each value is requests per second and burst.
+func getEnvRouteLimits(key string) map[string]RouteLimit {
+	limits := map[string]RouteLimit{}
+	for route, value := range getEnvMap(key) {
+		rps, burst, ok := strings.Cut(value, ":")
+		requestsPerSecond, err := strconv.ParseFloat(rps, 64)
+		if err != nil {
+			log.Printf("ignoring malformed limit %q for %s in %s", value, route, key)
+			continue
+		}
+		limit := RouteLimit{RequestsPerSecond: requestsPerSecond, Burst: int(requestsPerSecond)}
+		if ok {
+			if limit.Burst, err = strconv.Atoi(burst); err != nil {
+				log.Printf("ignoring malformed limit %q for %s in %s", value, route, key)
+				continue
+			}
+		}
+		limits[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	return limits
+}
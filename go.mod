@@ -6,7 +6,7 @@ require go.mongodb.org/mongo-driver v1.17.3
 
 require github.com/joho/godotenv v1.5.1
 
-require github.com/gocarina/gocsv v0.0.0-20240520201108-78e41c74b4b1
+require golang.org/x/text v0.23.0
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -27,5 +27,4 @@ require (
 	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/sync v0.12.0 // indirect
-	golang.org/x/text v0.23.0 // indirect
 )
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-mongo-app/services"
+)
+
+var auditEntry services.AuditEntries
+
+func getAuditEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := services.AuditFilter{
+		SwiftCode: strings.ToUpper(query.Get("swiftCode")),
+		Actor:     query.Get("actor"),
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			writeResponse(w, Response{Message: "from must be a RFC 3339 timestamp", Code: 400})
+			return
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			writeResponse(w, Response{Message: "to must be a RFC 3339 timestamp", Code: 400})
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.ParseInt(limit, 10, 64); err != nil {
+			writeResponse(w, Response{Message: "limit must be a number", Code: 400})
+			return
+		}
+	}
+
+	auditEntries, err := auditEntry.GetAuditEntries(filter, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(auditEntries)
+}
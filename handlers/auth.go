@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-mongo-app/auth"
+	"github.com/go-mongo-app/config"
+	"github.com/go-mongo-app/services"
+)
+
+var apiKey services.ApiKeys
+
+const apiKeysCollectionName string = "api_keys"
+
+const apiKeyHeader string = "X-API-Key"
+
+func writeResponse(w http.ResponseWriter, res Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.Code)
+	json.NewEncoder(w).Encode(res)
+}
+
+func parseRoleMapping(name string, roleMapping map[string]string) map[string]auth.Role {
+	mapping := map[string]auth.Role{}
+	for value, roleName := range roleMapping {
+		role, err := auth.ParseRole(roleName)
+		if err != nil {
+			log.Printf("ignoring %s role mapping %s=%s: %v", name, value, roleName, err)
+			continue
+		}
+		mapping[value] = role
+	}
+	return mapping
+}
+
+func newVerifier(cfg config.JWT) *auth.Verifier {
+	if cfg.JWKS == "" {
+		return nil
+	}
+
+	return &auth.Verifier{
+		Keys:     auth.NewJWKS(cfg.JWKS, cfg.JWKSRefresh),
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Roles:    auth.RoleMapper{Claim: cfg.RolesClaim, Mapping: parseRoleMapping("JWT", cfg.RoleMapping)},
+		Leeway:   cfg.Leeway,
+	}
+}
+
+// authenticate resolves the API key or bearer token sent with the request,
+// falling back to a verified TLS client certificate, and attaches the
+// resulting principal to the request context. Requests without credentials
+// pass through anonymously; whether that is allowed is up to requireRole.
+func authenticate(cfg config.Auth) func(http.Handler) http.Handler {
+	verifier := newVerifier(cfg.JWT)
+	certificateRoles := parseRoleMapping("mTLS", cfg.MTLSRoleMapping)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var principal auth.Principal
+			certificate := clientCertificate(r)
+			if rawKey := r.Header.Get(apiKeyHeader); rawKey != "" {
+				key, err := apiKey.GetApiKeyByKey(rawKey, apiKeysCollectionName)
+				if err != nil {
+					log.Printf("%s %s rejected: invalid API key", r.Method, r.URL.Path)
+					writeResponse(w, Response{
+						Message: "Invalid or revoked API key",
+						Code:    http.StatusUnauthorized,
+					})
+					return
+				}
+				principal = auth.Principal{
+					Name:   key.Name,
+					Method: "apikey",
+					Roles:  []auth.Role{auth.RoleForScope(key.Scope)},
+				}
+			} else if token, ok := bearerToken(r); ok && verifier != nil {
+				var err error
+				principal, err = verifier.Principal(token)
+				if err != nil {
+					log.Printf("%s %s rejected: %v", r.Method, r.URL.Path, err)
+					writeResponse(w, Response{
+						Message: "Invalid bearer token",
+						Code:    http.StatusUnauthorized,
+					})
+					return
+				}
+			} else if certificate != nil {
+				principal = auth.Principal{Name: certificate.Subject.CommonName, Method: "mtls"}
+				if role, ok := certificateRoles[certificate.Subject.CommonName]; ok {
+					principal.Roles = []auth.Role{role}
+				}
+			} else {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if certificate != nil {
+				principal.CertificateSubject = certificate.Subject.String()
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// clientCertificate returns the leaf of the verified client chain. Only
+// chains verified against the configured client CA are considered.
+func clientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func requireRole(cfg config.Auth, role auth.Role) func(http.Handler) http.Handler {
+	return requireRoleIf(cfg, role, func(r *http.Request) bool { return true })
+}
+
+// requireRoleIf enforces the role only for requests matching the condition,
+// e.g. deletes asking for a cascade.
+func requireRoleIf(cfg config.Auth, role auth.Role, condition func(r *http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.Enabled || (role == auth.RoleReader && !cfg.ProtectReads) || !condition(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, ok := auth.PrincipalFromContext(r.Context())
+			if !ok {
+				writeResponse(w, Response{
+					Message: "Missing " + apiKeyHeader + " header or bearer token",
+					Code:    http.StatusUnauthorized,
+				})
+				return
+			}
+
+			if !principal.HasRole(role) {
+				writeResponse(w, Response{
+					Message: "Operation requires " + string(role) + " role",
+					Code:    http.StatusForbidden,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestLogger has to run after authenticate so the caller identity is
+// already in the request context.
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		next.ServeHTTP(ww, r)
+
+		identity := "anonymous"
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			identity = principal.Method + ":" + principal.Name
+		}
+		log.Printf("%s %s %d %s identity=%s request=%s", r.Method, r.URL.Path, ww.Status(), time.Since(start), identity, middleware.GetReqID(r.Context()))
+	})
+}
+
+// auditContext hands the caller identity and request ID over to the
+// services layer, which stores them with every change it makes.
+func auditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := "anonymous"
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			actor = principal.Method + ":" + principal.Name
+		}
+		requestID := middleware.GetReqID(r.Context())
+		w.Header().Set(middleware.RequestIDHeader, requestID)
+		ctx := services.WithAuditContext(r.Context(), services.AuditContext{
+			Actor:     actor,
+			RequestID: requestID,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func getCountries(w http.ResponseWriter, r *http.Request) {
+	countries, err := swiftCode.GetCountries(collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(countries)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+)
+
+func getSwiftCodesNear(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	latitude, latErr := strconv.ParseFloat(query.Get("lat"), 64)
+	longitude, lonErr := strconv.ParseFloat(query.Get("lon"), 64)
+	if latErr != nil || lonErr != nil {
+		writeResponse(w, Response{Message: "lat and lon must be decimal degrees", Code: 400})
+		return
+	}
+	if err := services.ValidateCoordinates(latitude, longitude); err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+	radiusKm := 10.0
+	if value := query.Get("radiusKm"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			writeResponse(w, Response{Message: "radiusKm must be a number greater than 0", Code: 400})
+			return
+		}
+		radiusKm = parsed
+	}
+	opts, err := lookupOptions(r)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+	if !opts.AsOf.IsZero() {
+		writeResponse(w, Response{Message: "asOf isn't supported by near searches", Code: 400})
+		return
+	}
+
+	swiftCodes, err := swiftCode.FindSwiftCodesNear(r.Context(), latitude, longitude, radiusKm, opts, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(swiftCodes)
+}
+
+func importGazetteer(w http.ResponseWriter, r *http.Request) {
+	_, payload, err := readUpload(r)
+	if err != nil {
+		writeResponse(w, decodeErrorResponse(err))
+		return
+	}
+
+	places, err := parser.ReadPlaces(bytes.NewReader(payload))
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+	imported, err := swiftCode.ImportPlaces(r.Context(), places, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	writeResponse(w, Response{Message: fmt.Sprintf("Imported %d places", imported), Code: 201})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
@@ -34,7 +35,8 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 func createSwiftCode(w http.ResponseWriter, r *http.Request) {
 	err := json.NewDecoder(r.Body).Decode(&swiftCode)
 	if err != nil {
-		log.Fatal(err)
+		writeResponse(w, decodeErrorResponse(err))
+		return
 	}
 
 	swiftCode.SwiftCode = strings.ToUpper(swiftCode.SwiftCode)
@@ -65,7 +67,7 @@ func createSwiftCode(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	err = swiftCode.InsertSwiftCode(swiftCode, collectionName)
+	err = swiftCode.InsertSwiftCode(r.Context(), swiftCode, collectionName)
 	if err != nil {
 		errorRes := Response{
 			Message: err.Error(),
@@ -91,7 +93,13 @@ func createSwiftCode(w http.ResponseWriter, r *http.Request) {
 }
 
 func getSwiftCodes(w http.ResponseWriter, r *http.Request) {
-	swiftCodes, err := swiftCode.GetAllSwiftCodes(collectionName)
+	opts, err := lookupOptions(r)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+
+	swiftCodes, err := swiftCode.FindSwiftCodes(opts, collectionName)
 	if err != nil {
 		errorRes := Response{
 			Message: "Error during database request",
@@ -108,7 +116,13 @@ func getSwiftCodes(w http.ResponseWriter, r *http.Request) {
 
 func getSwiftCodeByCode(w http.ResponseWriter, r *http.Request) {
 	swiftCodeName := strings.ToUpper(chi.URLParam(r, "swift-code"))
-	swiftCode, err := swiftCode.GetSwiftCodeBySwiftCodeName(swiftCodeName, collectionName)
+	opts, err := lookupOptions(r)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+
+	swiftCode, err := swiftCode.FindSwiftCode(swiftCodeName, opts, collectionName)
 	if err != nil {
 		errorRes := Response{
 			Message: "Error during database request",
@@ -121,11 +135,16 @@ func getSwiftCodeByCode(w http.ResponseWriter, r *http.Request) {
 	if !swiftCode.IsHeadQuater {
 		res := NonHeadquaterResp{
 			Address:         swiftCode.Address,
+			PostalAddress:   swiftCode.PostalAddress,
 			BankName:        swiftCode.BankName,
 			CountryISO2Code: swiftCode.CountryISO2Code,
 			CountryName:     swiftCode.CountryName,
 			IsHeadQuater:    swiftCode.IsHeadQuater,
 			SwiftCode:       swiftCode.SwiftCode,
+			Classification:  swiftCode.Classification,
+			Warning:         services.ClassificationWarning(swiftCode.Classification),
+			ValidFrom:       swiftCode.ValidFrom,
+			ValidTo:         swiftCode.ValidTo,
 			Code:            201,
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -135,18 +154,23 @@ func getSwiftCodeByCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	prefix := swiftCode.SwiftCode[:8]
-	swiftCodes, err := swiftCode.GetAllBranchersWithPrefix(prefix, collectionName)
+	swiftCodes, err := swiftCode.FindBranches(prefix, opts, collectionName)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 	res := HeadQuaterResp{
 		Address:         swiftCode.Address,
+		PostalAddress:   swiftCode.PostalAddress,
 		BankName:        swiftCode.BankName,
 		CountryISO2Code: swiftCode.CountryISO2Code,
 		CountryName:     swiftCode.CountryName,
 		IsHeadQuater:    swiftCode.IsHeadQuater,
 		SwiftCode:       swiftCode.SwiftCode,
+		Classification:  swiftCode.Classification,
+		Warning:         services.ClassificationWarning(swiftCode.Classification),
+		ValidFrom:       swiftCode.ValidFrom,
+		ValidTo:         swiftCode.ValidTo,
 		Code:            201,
 		Branches:        swiftCodes,
 	}
@@ -157,7 +181,13 @@ func getSwiftCodeByCode(w http.ResponseWriter, r *http.Request) {
 
 func getSwiftCodesByISO2Code(w http.ResponseWriter, r *http.Request) {
 	isoCode := strings.ToUpper(chi.URLParam(r, "countryISO2code"))
-	swiftCodes, err := swiftCode.GetAllSwiftCoidesByISOCode(isoCode, collectionName)
+	opts, err := lookupOptions(r)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+
+	swiftCodes, err := swiftCode.FindSwiftCodesByISOCode(isoCode, opts, collectionName)
 	if err != nil {
 		errorRes := Response{
 			Message: "Error during database request",
@@ -187,6 +217,7 @@ func getSwiftCodesByISO2Code(w http.ResponseWriter, r *http.Request) {
 			CountryISO2Code: swiftCode.CountryISO2Code,
 			IsHeadQuater:    swiftCode.IsHeadQuater,
 			SwiftCode:       swiftCode.SwiftCode,
+			Classification:  swiftCode.Classification,
 		})
 	}
 
@@ -202,9 +233,61 @@ func getSwiftCodesByISO2Code(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
+func updateSwiftCode(w http.ResponseWriter, r *http.Request) {
+	swiftCodeName := strings.ToUpper(chi.URLParam(r, "swift-code"))
+
+	var update services.SwiftCodes
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		writeResponse(w, decodeErrorResponse(err))
+		return
+	}
+
+	update.CountryISO2Code = strings.ToUpper(update.CountryISO2Code)
+	update.CountryName = strings.ToUpper(update.CountryName)
+
+	err = swiftCode.UpdateSwiftCode(r.Context(), swiftCodeName, update, collectionName)
+	if err != nil {
+		errorRes := Response{
+			Message: err.Error(),
+			Code:    406,
+		}
+		writeResponse(w, errorRes)
+		return
+	}
+
+	res := Response{
+		Message: "Succesfully updated",
+		Code:    201,
+	}
+	writeResponse(w, res)
+}
+
+func isCascadeDelete(r *http.Request) bool {
+	return r.URL.Query().Get("cascade") == "true"
+}
+
 func deleteSwiftCode(w http.ResponseWriter, r *http.Request) {
 	swiftCodeName := strings.ToUpper(chi.URLParam(r, "swift-code"))
 
+	if swiftCodeName[len(swiftCodeName)-3:] == "XXX" && isCascadeDelete(r) {
+		deleted, err := swiftCode.DeleteSwiftCodesWithPrefix(r.Context(), swiftCodeName[:8], r.URL.Query().Get("reason"), collectionName)
+		if err != nil {
+			errorRes := Response{
+				Message: err.Error(),
+				Code:    406,
+			}
+			writeResponse(w, errorRes)
+			return
+		}
+
+		writeResponse(w, Response{
+			Message: fmt.Sprintf("Succesfully deleted %d swift codes", deleted),
+			Code:    201,
+		})
+		return
+	}
+
 	if swiftCodeName[len(swiftCodeName)-3:] == "XXX" {
 		swiftCodes, err := swiftCode.GetAllBranchersWithPrefix(swiftCodeName[:8], collectionName)
 		log.Println(swiftCodes)
@@ -227,7 +310,7 @@ func deleteSwiftCode(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	err := swiftCode.DeleteSwiftCode(swiftCodeName, collectionName)
+	err := swiftCode.DeleteSwiftCode(r.Context(), swiftCodeName, r.URL.Query().Get("reason"), collectionName)
 	if err != nil {
 		errorRes := Response{
 			Message: err.Error(),
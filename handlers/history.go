@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-mongo-app/services"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func lookupOptions(r *http.Request) (services.LookupOptions, error) {
+	var opts services.LookupOptions
+	query := r.URL.Query()
+
+	if asOf := query.Get("asOf"); asOf != "" {
+		t, err := services.ParseDate(asOf)
+		if err != nil {
+			return opts, fmt.Errorf("asOf must be a RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		opts.AsOf = t
+	}
+	if effectiveDate := query.Get("effectiveDate"); effectiveDate != "" {
+		t, err := services.ParseDate(effectiveDate)
+		if err != nil {
+			return opts, fmt.Errorf("effectiveDate must be a RFC 3339 timestamp or a YYYY-MM-DD date")
+		}
+		opts.EffectiveAt = t
+	}
+	opts.IncludeDeleted = query.Get("includeDeleted") == "true"
+	classifications, err := services.ParseClassifications(query.Get("classification"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Classifications = classifications
+	opts.BankName = query.Get("bankName")
+	opts.TownName = query.Get("town")
+	return opts, nil
+}
+
+func getSwiftCodeHistory(w http.ResponseWriter, r *http.Request) {
+	swiftCodeName := strings.ToUpper(chi.URLParam(r, "swift-code"))
+
+	versions, err := swiftCode.GetSwiftCodeHistory(swiftCodeName, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 406})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(versions)
+}
+
+func restoreSwiftCode(w http.ResponseWriter, r *http.Request) {
+	swiftCodeName := strings.ToUpper(chi.URLParam(r, "swift-code"))
+
+	if len(swiftCodeName) == 11 && swiftCodeName[8:] != "XXX" {
+		if _, err := swiftCode.GetHeadquater(swiftCodeName[:8], collectionName); err != nil {
+			writeResponse(w, Response{Message: "Can't restore branch code without main code", Code: 406})
+			return
+		}
+	}
+
+	if err := swiftCode.RestoreSwiftCode(r.Context(), swiftCodeName, collectionName); err != nil {
+		writeResponse(w, Response{Message: "Couldn't find deleted swift code with provided name", Code: 406})
+		return
+	}
+
+	writeResponse(w, Response{Message: "Succesfully restored", Code: 201})
+}
+
+func getUpcomingChanges(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		if days, err = strconv.Atoi(value); err != nil || days <= 0 {
+			writeResponse(w, Response{Message: "days must be a positive number", Code: 400})
+			return
+		}
+	}
+
+	from := time.Now().UTC()
+	changes, err := swiftCode.GetUpcomingChanges(from, from.AddDate(0, 0, days), collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(changes)
+}
+
+func getLocalTime(w http.ResponseWriter, r *http.Request) {
+	swiftCodeName := strings.ToUpper(chi.URLParam(r, "swift-code"))
+
+	localTime, err := swiftCode.GetLocalTime(swiftCodeName, collectionName)
+	if err == mongo.ErrNoDocuments {
+		writeResponse(w, Response{Message: "Couldn't find swift code with provided name", Code: 406})
+		return
+	}
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 406})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(localTime)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const bankCodesCollectionName string = "bank_codes"
+
+var bankCode services.BankCodes
+
+func getIban(w http.ResponseWriter, r *http.Request) {
+	iban, err := services.ParseIban(chi.URLParam(r, "iban"))
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+
+	resolution, err := swiftCode.ResolveIban(iban, bankCodesCollectionName, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(resolution)
+}
+
+func getBankCode(w http.ResponseWriter, r *http.Request) {
+	scheme := strings.ToLower(chi.URLParam(r, "scheme"))
+	isoCode := strings.ToUpper(r.URL.Query().Get("country"))
+	code := chi.URLParam(r, "code")
+	if _, err := services.ValidateBankCode(scheme, isoCode, code); err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+
+	resolution, err := swiftCode.ResolveBankCode(scheme, isoCode, code, bankCodesCollectionName, collectionName)
+	if err == mongo.ErrNoDocuments {
+		writeResponse(w, Response{Message: "Couldn't find bank code with provided name", Code: 406})
+		return
+	}
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(resolution)
+}
+
+func getSwiftCodeBankCodes(w http.ResponseWriter, r *http.Request) {
+	swiftCodeName := strings.ToUpper(chi.URLParam(r, "swift-code"))
+	bankCodes, err := bankCode.GetBankCodesOfSwiftCode(swiftCodeName, bankCodesCollectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(bankCodes)
+}
+
+func importBankCodes(w http.ResponseWriter, r *http.Request) {
+	_, payload, err := readUpload(r)
+	if err != nil {
+		writeResponse(w, decodeErrorResponse(err))
+		return
+	}
+
+	bankCodes, err := parser.ReadBankCodes(bytes.NewReader(payload))
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+	imported, err := bankCode.ImportBankCodes(r.Context(), bankCodes, bankCodesCollectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	writeResponse(w, Response{Message: fmt.Sprintf("Imported %d bank codes", imported), Code: 201})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+)
+
+const importJobsCollectionName string = "import_jobs"
+const watchedFilesCollectionName string = "watched_files"
+
+var importJob services.ImportJobs
+var watchedFile services.WatchedFiles
+
+func writeImportJob(w http.ResponseWriter, job services.ImportJobs, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(job)
+}
+
+// readUpload returns the uploaded file, sent either as the "file" field of a
+// multipart form or as the raw body.
+func readUpload(r *http.Request) (string, []byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return "", nil, err
+		}
+		defer file.Close()
+		payload, err := io.ReadAll(file)
+		return header.Filename, payload, err
+	}
+
+	fileName := r.URL.Query().Get("filename")
+	if fileName == "" {
+		fileName = "upload.csv"
+	}
+	payload, err := io.ReadAll(r.Body)
+	return fileName, payload, err
+}
+
+func createImportJob(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" {
+		if _, err := parser.FormatByName(format); err != nil {
+			writeResponse(w, Response{Message: err.Error(), Code: 400})
+			return
+		}
+	}
+
+	fileName, payload, err := readUpload(r)
+	if err != nil {
+		writeResponse(w, decodeErrorResponse(err))
+		return
+	}
+	if len(payload) == 0 {
+		writeResponse(w, Response{Message: "Uploaded file is empty", Code: 400})
+		return
+	}
+
+	job, err := importJob.CreateImportJob(r.Context(), fileName, format, bytes.NewReader(payload), importJobsCollectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+	go parser.RunImportJob(context.Background(), job.ID, importJobsCollectionName, collectionName)
+
+	w.Header().Set("Location", "/v1/imports/"+job.ID)
+	writeImportJob(w, job, http.StatusAccepted)
+}
+
+func getImportJobs(w http.ResponseWriter, r *http.Request) {
+	limit := int64(50)
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.ParseInt(value, 10, 64); err != nil || limit <= 0 {
+			writeResponse(w, Response{Message: "limit must be a positive number", Code: 400})
+			return
+		}
+	}
+
+	jobs, err := importJob.GetAllImportJobs(limit, importJobsCollectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(jobs)
+}
+
+func getImportJob(w http.ResponseWriter, r *http.Request) {
+	job, err := importJob.GetImportJob(chi.URLParam(r, "id"), importJobsCollectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 404})
+		return
+	}
+	writeImportJob(w, job, 200)
+}
+
+func cancelImportJob(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := importJob.GetImportJob(id, importJobsCollectionName); err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 404})
+		return
+	}
+
+	job, err := importJob.CancelImportJob(id, importJobsCollectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 409})
+		return
+	}
+	writeImportJob(w, job, http.StatusAccepted)
+}
+
+func getWatchedFiles(w http.ResponseWriter, r *http.Request) {
+	limit := int64(50)
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.ParseInt(value, 10, 64); err != nil || limit <= 0 {
+			writeResponse(w, Response{Message: "limit must be a positive number", Code: 400})
+			return
+		}
+	}
+
+	watchedFiles, err := watchedFile.GetWatchedFiles(limit, watchedFilesCollectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(watchedFiles)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-mongo-app/services"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func getInstitutions(w http.ResponseWriter, r *http.Request) {
+	opts, err := lookupOptions(r)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+
+	institutions, err := swiftCode.FindInstitutions(opts, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(institutions)
+}
+
+func getInstitution(w http.ResponseWriter, r *http.Request) {
+	institutionCode := strings.ToUpper(chi.URLParam(r, "code"))
+	if err := services.ValidateInstitutionCode(institutionCode); err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+	opts, err := lookupOptions(r)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+
+	institution, err := swiftCode.FindInstitution(institutionCode, opts, collectionName)
+	if err == mongo.ErrNoDocuments {
+		writeResponse(w, Response{Message: "Couldn't find institution with provided code", Code: 406})
+		return
+	}
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(institution)
+}
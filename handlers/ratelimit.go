@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-mongo-app/auth"
+	"github.com/go-mongo-app/config"
+)
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	fullAt time.Time
+}
+
+// rateLimiter keeps one token bucket per route limit and client. Buckets
+// which had time to refill completely are dropped, since a fresh bucket is
+// equivalent.
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+const sweepInterval = time.Minute
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: map[string]*tokenBucket{}, lastSweep: time.Now()}
+}
+
+// allow takes a token from the bucket and otherwise reports how long the
+// client has to wait for the next one. The limit must have a positive rate.
+func (l *rateLimiter) allow(key string, limit config.RouteLimit, now time.Time) (bool, time.Duration) {
+	burst := math.Max(float64(limit.Burst), 1)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*limit.RequestsPerSecond)
+	bucket.last = now
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	bucket.fullAt = now.Add(seconds((burst - bucket.tokens) / limit.RequestsPerSecond))
+	if allowed {
+		return true, 0
+	}
+	return false, seconds((1 - bucket.tokens) / limit.RequestsPerSecond)
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if now.After(bucket.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
+
+// rateLimit has to run after authenticate so that callers with credentials
+// are limited per identity rather than per address.
+func rateLimit(cfg config.RateLimit) func(http.Handler) http.Handler {
+	limiter := newRateLimiter()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !cfg.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			route := "*"
+			limit := cfg.Default
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.Routes != nil {
+				pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+				if routeLimit, ok := cfg.Routes[r.Method+" "+pattern]; ok {
+					route = r.Method + " " + pattern
+					limit = routeLimit
+				}
+			}
+
+			if limit.RequestsPerSecond <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, wait := limiter.allow(route+"|"+clientKey(r, cfg.TrustProxy), limit, time.Now())
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeResponse(w, Response{
+					Message: "Too many requests",
+					Code:    http.StatusTooManyRequests,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientKey(r *http.Request, trustProxy bool) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return principal.Method + ":" + principal.Name
+	}
+
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			client, _, _ := strings.Cut(forwarded, ",")
+			return "ip:" + strings.TrimSpace(client)
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return "ip:" + realIP
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// limitBody rejects request bodies larger than maxBytes. Handlers see the
+// limit as a *http.MaxBytesError while decoding.
+func limitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if maxBytes <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > maxBytes {
+				writeResponse(w, Response{
+					Message: "Request body too large",
+					Code:    http.StatusRequestEntityTooLarge,
+				})
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decodeErrorResponse maps a failed body decode onto a client error.
+func decodeErrorResponse(err error) Response {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return Response{
+			Message: "Request body too large",
+			Code:    http.StatusRequestEntityTooLarge,
+		}
+	}
+	return Response{
+		Message: "Invalid request body",
+		Code:    http.StatusBadRequest,
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-mongo-app/services"
+)
+
+func getDataQualityReport(w http.ResponseWriter, r *http.Request) {
+	samples := 10
+	if value := r.URL.Query().Get("samples"); value != "" {
+		var err error
+		if samples, err = strconv.Atoi(value); err != nil || samples < 0 {
+			writeResponse(w, Response{Message: "samples must be a non-negative number", Code: 400})
+			return
+		}
+	}
+
+	report, err := swiftCode.GetDataQualityReport(samples, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(report)
+}
+
+func getOrphanReport(w http.ResponseWriter, r *http.Request) {
+	report, err := swiftCode.GetOrphanReport(collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(report)
+}
+
+func reconcileOrphanBranches(w http.ResponseWriter, r *http.Request) {
+	policy, err := services.ParseOrphanPolicy(r.URL.Query().Get("policy"))
+	if err != nil || policy == services.OrphanFlag {
+		writeResponse(w, Response{Message: "policy must be synthesize or reject", Code: 400})
+		return
+	}
+
+	changed, err := swiftCode.ReconcileOrphanBranches(r.Context(), policy, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 500})
+		return
+	}
+
+	if policy == services.OrphanSynthesize {
+		writeResponse(w, Response{Message: fmt.Sprintf("Created %d placeholder headquarters", changed), Code: 200})
+		return
+	}
+	writeResponse(w, Response{Message: fmt.Sprintf("Deleted %d orphaned branches", changed), Code: 200})
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"time"
+
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/cors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-mongo-app/auth"
+	"github.com/go-mongo-app/config"
 	"github.com/go-mongo-app/services"
 )
 
@@ -13,21 +17,31 @@ type Response struct {
 
 type NonHeadquaterResp struct {
 	Address         string
+	PostalAddress   *services.PostalAddresses `json:",omitempty"`
 	BankName        string
 	CountryISO2Code string
 	CountryName     string
 	IsHeadQuater    bool
 	SwiftCode       string
+	Classification  string
+	Warning         string     `json:",omitempty"`
+	ValidFrom       *time.Time `json:",omitempty"`
+	ValidTo         *time.Time `json:",omitempty"`
 	Code            int
 }
 
 type HeadQuaterResp struct {
 	Address         string
+	PostalAddress   *services.PostalAddresses `json:",omitempty"`
 	BankName        string
 	CountryISO2Code string
 	CountryName     string
 	IsHeadQuater    bool
 	SwiftCode       string
+	Classification  string
+	Warning         string     `json:",omitempty"`
+	ValidFrom       *time.Time `json:",omitempty"`
+	ValidTo         *time.Time `json:",omitempty"`
 	Branches        []services.SwiftCodeArrayElem
 	Code            int
 }
@@ -39,26 +53,70 @@ type CountryResp struct {
 	Code            int
 }
 
-func CreateRouter() *chi.Mux {
+func CreateRouter(cfg config.Config) *chi.Mux {
 
 	router := chi.NewRouter()
 
-	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTION"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CRSF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	router.Use(securityHeaders(cfg.SecurityHeaders))
+	if handler := corsHandler(cfg.CORS); handler != nil {
+		router.Use(handler)
+	}
 
 	router.Route("/v1", func(router chi.Router) {
+		router.Use(middleware.RequestID)
+		router.Use(authenticate(cfg.Auth))
+		router.Use(requestLogger)
+		router.Use(rateLimit(cfg.RateLimit))
+		router.Use(auditContext)
+
 		router.Get("/healthcheck", healthCheck)
-		router.Post("/swift-codes", createSwiftCode)
-		router.Get("/swift-codes", getSwiftCodes)
-		router.Get("/swift-codes/{swift-code}", getSwiftCodeByCode)
-		router.Get("/swift-codes/country/{countryISO2code}", getSwiftCodesByISO2Code)
-		router.Delete("/swift-codes/{swift-code}", deleteSwiftCode)
+
+		router.Group(func(router chi.Router) {
+			router.Use(requireRole(cfg.Auth, auth.RoleReader))
+			router.Get("/swift-codes", getSwiftCodes)
+			router.Get("/swift-codes/upcoming", getUpcomingChanges)
+			router.Get("/swift-codes/near", getSwiftCodesNear)
+			router.Get("/swift-codes/search", searchSwiftCodes)
+			router.Get("/swift-codes/{swift-code}", getSwiftCodeByCode)
+			router.Get("/swift-codes/{swift-code}/history", getSwiftCodeHistory)
+			router.Get("/swift-codes/{swift-code}/local-time", getLocalTime)
+			router.Get("/swift-codes/{swift-code}/bank-codes", getSwiftCodeBankCodes)
+			router.Get("/swift-codes/country/{countryISO2code}", getSwiftCodesByISO2Code)
+			router.Get("/countries", getCountries)
+			router.Get("/institutions", getInstitutions)
+			router.Get("/institutions/{code}", getInstitution)
+			router.Get("/iban/{iban}", getIban)
+			router.Get("/bank-codes/{scheme}/{code}", getBankCode)
+			router.Get("/imports", getImportJobs)
+			router.Get("/imports/watched", getWatchedFiles)
+			router.Get("/imports/{id}", getImportJob)
+			router.Get("/reports/data-quality", getDataQualityReport)
+			router.Get("/reports/orphan-branches", getOrphanReport)
+		})
+
+		router.Group(func(router chi.Router) {
+			router.Use(requireRole(cfg.Auth, auth.RoleEditor))
+			router.Use(limitBody(cfg.RateLimit.MaxBodyBytes))
+			router.Post("/swift-codes", createSwiftCode)
+			router.Put("/swift-codes/{swift-code}", updateSwiftCode)
+			router.Post("/swift-codes/{swift-code}/restore", restoreSwiftCode)
+			router.With(requireRoleIf(cfg.Auth, auth.RoleAdmin, isCascadeDelete)).Delete("/swift-codes/{swift-code}", deleteSwiftCode)
+		})
+
+		router.Group(func(router chi.Router) {
+			router.Use(requireRole(cfg.Auth, auth.RoleEditor))
+			router.Use(limitBody(cfg.Imports.MaxBytes))
+			router.Post("/imports", createImportJob)
+			router.Post("/imports/{id}/cancel", cancelImportJob)
+			router.Post("/bank-codes", importBankCodes)
+			router.Post("/gazetteer", importGazetteer)
+		})
+
+		router.Group(func(router chi.Router) {
+			router.Use(requireRole(cfg.Auth, auth.RoleAdmin))
+			router.Get("/audit", getAuditEntries)
+			router.With(limitBody(cfg.RateLimit.MaxBodyBytes)).Post("/reports/orphan-branches/reconcile", reconcileOrphanBranches)
+		})
 	})
 
 	return router
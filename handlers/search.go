@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func searchSwiftCodes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeResponse(w, Response{Message: "q can't be empty", Code: 400})
+		return
+	}
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			writeResponse(w, Response{Message: "limit must be a number between 1 and 1000", Code: 400})
+			return
+		}
+		limit = parsed
+	}
+	opts, err := lookupOptions(r)
+	if err != nil {
+		writeResponse(w, Response{Message: err.Error(), Code: 400})
+		return
+	}
+
+	swiftCodes, err := swiftCode.SearchSwiftCodes(query, limit, opts, collectionName)
+	if err != nil {
+		writeResponse(w, Response{Message: "Error during database request", Code: 500})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	json.NewEncoder(w).Encode(swiftCodes)
+}
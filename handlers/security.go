@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/cors"
+	"github.com/go-mongo-app/config"
+)
+
+// corsHandler returns nil when no origins are configured, in which case
+// browsers fall back to the same-origin policy.
+func corsHandler(cfg config.CORS) func(http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil
+	}
+
+	allowCredentials := cfg.AllowCredentials
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" && allowCredentials {
+			log.Println("CORS credentials can't be combined with the * origin, disabling credentials")
+			allowCredentials = false
+		}
+	}
+
+	return cors.Handler(cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: allowCredentials,
+		MaxAge:           cfg.MaxAge,
+	})
+}
+
+func securityHeaders(cfg config.SecurityHeaders) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Enabled {
+				header := w.Header()
+				if cfg.HSTSMaxAge > 0 {
+					hsts := "max-age=" + strconv.Itoa(cfg.HSTSMaxAge)
+					if cfg.HSTSIncludeSubdomains {
+						hsts += "; includeSubDomains"
+					}
+					header.Set("Strict-Transport-Security", hsts)
+				}
+				if cfg.FrameOptions != "" {
+					header.Set("X-Frame-Options", cfg.FrameOptions)
+				}
+				header.Set("X-Content-Type-Options", "nosniff")
+				header.Set("Referrer-Policy", "no-referrer")
+				header.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -3,19 +3,25 @@ package main
 import (
 	"context"
 	"log"
-	"net/http"
+	"os"
 	"time"
 
+	"github.com/go-mongo-app/cli"
+	"github.com/go-mongo-app/config"
 	"github.com/go-mongo-app/db"
 	"github.com/go-mongo-app/handlers"
 	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/server"
 	"github.com/go-mongo-app/services"
 )
 
 func main() {
 	isTested := false
-	connectionString := "mongodb://mongodb:27017"
-	mongoClient, err := db.ConnectToMongo(isTested, connectionString)
+	cfg, err := config.Load(isTested)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mongoClient, err := db.ConnectToMongo(isTested, cfg.MongoURI)
 	if err != nil {
 		log.Panic()
 	}
@@ -30,10 +36,57 @@ func main() {
 	}()
 
 	services.New(mongoClient)
+
+	if cfg.Imports.ProfilesFile != "" {
+		if err = parser.LoadProfiles(cfg.Imports.ProfilesFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if parser.OrphanPolicy, err = services.ParseOrphanPolicy(cfg.Imports.OrphanBranchPolicy); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(os.Args) > 1 {
+		if err = cli.Run(os.Args[1:]); err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	err = parser.ParseCSVToMongoDatabase()
 	if err != nil {
 		log.Panic()
 	}
-	log.Println("Server running in port", 8080)
-	log.Fatal(http.ListenAndServe(":8080", handlers.CreateRouter()))
+	if err = parser.ParseBankCodesToMongoDatabase(); err != nil {
+		log.Panic()
+	}
+	if err = parser.ParseGazetteerToMongoDatabase(); err != nil {
+		log.Panic()
+	}
+	var swiftCodes services.SwiftCodes
+	if err = swiftCodes.RunMigrations(context.Background(), "swift_codes"); err != nil {
+		log.Panic()
+	}
+
+	go parser.ResumeImportJobs(context.Background(), "import_jobs", "swift_codes")
+
+	if cfg.Watch.Dir != "" {
+		watcher := parser.Watcher{
+			Dir:                 cfg.Watch.Dir,
+			Interval:            cfg.Watch.Interval,
+			Settle:              cfg.Watch.Settle,
+			Format:              cfg.Watch.Format,
+			CollectionName:      "swift_codes",
+			JobsCollectionName:  "import_jobs",
+			FilesCollectionName: "watched_files",
+		}
+		go watcher.Run(context.Background())
+	}
+
+	if cfg.SoftDelete.Retention > 0 {
+		go swiftCodes.RunPurgeJob(context.Background(), cfg.SoftDelete.Retention, cfg.SoftDelete.PurgeInterval, "swift_codes")
+	}
+	log.Fatal(server.ListenAndServe(cfg.Server, handlers.CreateRouter(cfg)))
 }
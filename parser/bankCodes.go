@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-mongo-app/services"
+)
+
+// bankCodeColumns are the columns of bank_codes.csv. An optional SCHEME
+// column names the clearing scheme of each code.
+var bankCodeColumns = []string{"COUNTRY ISO2 CODE", "BANK CODE", "SWIFT CODE"}
+
+// ReadBankCodes parses a bank code table: a CSV file with the
+// bankCodeColumns in any order. Codes with an empty SCHEME take the scheme of
+// their country and are checked against it. Tables without the SCHEME column
+// hold the bank codes found in IBANs, as before schemes were introduced, so
+// their codes are SchemeBankCode. Swift codes of eight characters stand for
+// their headquarter.
+func ReadBankCodes(in io.Reader) ([]services.BankCodes, error) {
+	bankCodes := []services.BankCodes{}
+	err := readTable(in, "bank code file", bankCodeColumns, func(row tableRows) error {
+		bankCode := services.BankCodes{
+			CountryISO2Code: strings.ToUpper(row.Value("COUNTRY ISO2 CODE")),
+			Scheme:          strings.ToLower(row.Value("SCHEME")),
+			SwiftCode:       strings.ToUpper(row.Value("SWIFT CODE")),
+		}
+		if _, ok := services.LookupCountry(bankCode.CountryISO2Code); !ok {
+			return fmt.Errorf("%s is not an ISO 3166-1 country code", bankCode.CountryISO2Code)
+		}
+		if bankCode.Scheme == "" && row.Has("SCHEME") {
+			bankCode.Scheme = services.CountryClearingScheme(bankCode.CountryISO2Code)
+		} else if bankCode.Scheme == "" {
+			bankCode.Scheme = services.SchemeBankCode
+		}
+		var err error
+		bankCode.BankCode, err = services.ValidateBankCode(bankCode.Scheme, bankCode.CountryISO2Code, strings.ToUpper(row.Value("BANK CODE")))
+		if err != nil {
+			return err
+		}
+		if len(bankCode.SwiftCode) == 8 {
+			bankCode.SwiftCode += "XXX"
+		}
+		if len(bankCode.SwiftCode) != 11 {
+			return fmt.Errorf("swift code must be 8 or 11 characters")
+		}
+		bankCodes = append(bankCodes, bankCode)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bankCodes, nil
+}
+
+// ImportBankCodesFile reads a bank code table into collectionName.
+func ImportBankCodesFile(ctx context.Context, path string, collectionName string) (int, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	bankCodes, err := ReadBankCodes(in)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	var bankCode services.BankCodes
+	return bankCode.ImportBankCodes(ctx, bankCodes, collectionName)
+}
+
+// ParseBankCodesToMongoDatabase imports bank_codes.csv at startup.
+func ParseBankCodesToMongoDatabase() error {
+	return importTableFile("bank_codes.csv", "bank codes", func(ctx context.Context, path string) (int, error) {
+		return ImportBankCodesFile(ctx, path, "bank_codes")
+	})
+}
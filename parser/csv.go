@@ -0,0 +1,272 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-mongo-app/services"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// csvFormat reads and writes CSV files described by a profile.
+type csvFormat struct {
+	profile CSVProfiles
+}
+
+func (f csvFormat) Name() string {
+	return f.profile.Name
+}
+
+// Detect matches files whose header, split with the profile delimiter, has
+// the swift code column. Files without a header are never detected.
+func (f csvFormat) Detect(head []byte) bool {
+	if !f.profile.HasHeader {
+		return false
+	}
+	records, err := f.records(bytes.NewReader(head))
+	if err != nil {
+		return false
+	}
+	header, _, err := records()
+	if err != nil {
+		return false
+	}
+
+	for _, name := range header {
+		for _, column := range f.profile.Columns["swiftcode"] {
+			if normalizeHeader(name) == normalizeHeader(column) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decoder converts the profile encoding to UTF-8 and drops a byte order
+// mark, which also overrides the profile encoding.
+func (f csvFormat) decoder(in io.Reader) (io.Reader, error) {
+	enc, err := f.profile.encoding()
+	if err != nil {
+		return nil, err
+	}
+	return transform.NewReader(in, unicode.BOMOverride(enc.NewDecoder())), nil
+}
+
+// records returns a function reading one record at a time together with
+// the line it starts on.
+func (f csvFormat) records(in io.Reader) (func() ([]string, int, error), error) {
+	decoded, err := f.decoder(in)
+	if err != nil {
+		return nil, err
+	}
+	delimiter, err := f.profile.delimiter()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.profile.Quoting == QuotingNone {
+		scanner := bufio.NewScanner(decoded)
+		scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+		line := 0
+		return func() ([]string, int, error) {
+			for scanner.Scan() {
+				line++
+				text := strings.TrimRight(scanner.Text(), "\r")
+				if text == "" {
+					continue
+				}
+				return strings.Split(text, string(delimiter)), line, nil
+			}
+			if err := scanner.Err(); err != nil {
+				return nil, line, err
+			}
+			return nil, line, io.EOF
+		}, nil
+	}
+
+	reader := csv.NewReader(decoded)
+	reader.Comma = delimiter
+	reader.LazyQuotes = f.profile.Quoting == QuotingLazy
+	reader.FieldsPerRecord = -1
+	return func() ([]string, int, error) {
+		record, err := reader.Read()
+		if err != nil {
+			return nil, 0, err
+		}
+		line, _ := reader.FieldPos(0)
+		return record, line, nil
+	}, nil
+}
+
+// columnIndexes resolves the columns of every mapped field. Columns missing
+// from the header are left out, except for the swift code.
+func (f csvFormat) columnIndexes(header []string) (map[string][]int, error) {
+	positions := map[string]int{}
+	for i, name := range header {
+		positions[normalizeHeader(name)] = i
+	}
+
+	indexes := map[string][]int{}
+	for field, columns := range f.profile.Columns {
+		for _, column := range columns {
+			if !f.profile.HasHeader {
+				n, err := strconv.Atoi(column)
+				if err != nil {
+					return nil, fmt.Errorf("column %q of %s must be a number", column, field)
+				}
+				indexes[field] = append(indexes[field], n-1)
+				continue
+			}
+			if i, ok := positions[normalizeHeader(column)]; ok {
+				indexes[field] = append(indexes[field], i)
+			}
+		}
+	}
+	if len(indexes["swiftcode"]) == 0 {
+		return nil, fmt.Errorf("missing swift code column %s", strings.Join(f.profile.Columns["swiftcode"], ", "))
+	}
+	return indexes, nil
+}
+
+func (f csvFormat) Read(in io.Reader) ([]*DirectoryRows, error) {
+	records, err := f.records(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var header []string
+	if f.profile.HasHeader {
+		if header, _, err = records(); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("missing header")
+			}
+			return nil, err
+		}
+	}
+	indexes, err := f.columnIndexes(header)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []*DirectoryRows{}
+	for {
+		record, line, err := records()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := func(field string) string {
+			var values []string
+			for _, i := range indexes[field] {
+				if i < len(record) && (len(indexes[field]) == 1 || strings.TrimSpace(record[i]) != "") {
+					values = append(values, record[i])
+				}
+			}
+			return strings.Join(values, " ")
+		}
+		rows = append(rows, &DirectoryRows{
+			SwiftCodes: services.SwiftCodes{
+				SwiftCode:       value("swiftcode"),
+				CountryISO2Code: value("countryiso2code"),
+				CodeType:        value("codetype"),
+				BankName:        value("bankname"),
+				Address:         value("address"),
+				TownName:        value("townname"),
+				CountryName:     value("countryname"),
+				TimeZone:        value("timezone"),
+			},
+			ValidFrom:   value("validfrom"),
+			ValidTo:     value("validto"),
+			HasValidity: len(indexes["validfrom"]) > 0 || len(indexes["validto"]) > 0,
+			Line:        line,
+		})
+	}
+}
+
+// Write puts a field mapped to several columns into the first one.
+func (f csvFormat) Write(out io.Writer, swiftCodes []services.SwiftCodes) error {
+	enc, err := f.profile.encoding()
+	if err != nil {
+		return err
+	}
+	delimiter, err := f.profile.delimiter()
+	if err != nil {
+		return err
+	}
+
+	// Columns in the order of profileFields, or of their numbers.
+	var fields []string
+	var header []string
+	width := 0
+	for _, field := range profileFields {
+		if columns := f.profile.Columns[field]; len(columns) > 0 {
+			fields = append(fields, field)
+			header = append(header, columns[0])
+			if n, err := strconv.Atoi(columns[0]); err == nil && n > width {
+				width = n
+			}
+		}
+	}
+	position := func(i int, field string) int {
+		if f.profile.HasHeader {
+			return i
+		}
+		n, _ := strconv.Atoi(f.profile.Columns[field][0])
+		return n - 1
+	}
+	if f.profile.HasHeader {
+		width = len(fields)
+	}
+
+	encoded := transform.NewWriter(out, enc.NewEncoder())
+	w := csv.NewWriter(encoded)
+	w.Comma = delimiter
+	write := func(record []string) error {
+		if f.profile.Quoting == QuotingNone {
+			_, err := io.WriteString(encoded, strings.Join(record, string(delimiter))+"\n")
+			return err
+		}
+		return w.Write(record)
+	}
+
+	if f.profile.HasHeader {
+		if err = write(header); err != nil {
+			return err
+		}
+	}
+	for _, swiftCode := range swiftCodes {
+		values := map[string]string{
+			"countryiso2code": swiftCode.CountryISO2Code,
+			"swiftcode":       swiftCode.SwiftCode,
+			"codetype":        swiftCode.CodeType,
+			"bankname":        swiftCode.BankName,
+			"address":         swiftCode.Address,
+			"townname":        swiftCode.TownName,
+			"countryname":     swiftCode.CountryName,
+			"timezone":        swiftCode.TimeZone,
+			"validfrom":       formatValidity(swiftCode.ValidFrom),
+			"validto":         formatValidity(swiftCode.ValidTo),
+		}
+		record := make([]string, width)
+		for i, field := range fields {
+			record[position(i, field)] = values[field]
+		}
+		if err = write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return err
+	}
+	return encoded.Close()
+}
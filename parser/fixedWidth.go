@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-mongo-app/services"
+)
+
+type fixedWidthField struct {
+	name  string
+	width int
+}
+
+// fixedWidthLayout is the FI record of the BIC directory flat file. Widths
+// are in characters; fields the app doesn't store are skipped on read and
+// left blank on write.
+var fixedWidthLayout = []fixedWidthField{
+	{"TAG", 2},
+	{"MODIFICATION FLAG", 1},
+	{"BIC CODE", 8},
+	{"BRANCH CODE", 3},
+	{"INSTITUTION NAME", 105},
+	{"BRANCH INFORMATION", 70},
+	{"CITY HEADING", 35},
+	{"SUBTYPE INDICATION", 4},
+	{"VALUE ADDED SERVICES", 60},
+	{"EXTRA INFORMATION", 35},
+	{"PHYSICAL ADDRESS 1", 35},
+	{"PHYSICAL ADDRESS 2", 35},
+	{"PHYSICAL ADDRESS 3", 35},
+	{"PHYSICAL ADDRESS 4", 35},
+	{"LOCATION", 35},
+	{"COUNTRY NAME", 70},
+	{"POB NUMBER", 35},
+	{"POB LOCATION", 35},
+	{"POB COUNTRY NAME", 70},
+}
+
+var fixedWidthAddress = []string{"PHYSICAL ADDRESS 1", "PHYSICAL ADDRESS 2", "PHYSICAL ADDRESS 3", "PHYSICAL ADDRESS 4"}
+
+const (
+	fixedWidthTag = "FI"
+	// fixedWidthDeleted marks records removed from the directory.
+	fixedWidthDeleted = "D"
+)
+
+// fixedWidthMinLength covers the fields up to COUNTRY NAME; trailing fields
+// may be cut off.
+func fixedWidthMinLength() int {
+	length := 0
+	for _, field := range fixedWidthLayout {
+		if field.name == "COUNTRY NAME" {
+			return length
+		}
+		length += field.width
+	}
+	return length
+}
+
+func splitFixedWidth(line string) map[string]string {
+	runes := []rune(line)
+	fields := map[string]string{}
+	offset := 0
+	for _, field := range fixedWidthLayout {
+		end := offset + field.width
+		if offset < len(runes) {
+			if end > len(runes) {
+				end = len(runes)
+			}
+			fields[field.name] = strings.TrimRight(string(runes[offset:end]), " ")
+		}
+		offset += field.width
+	}
+	return fields
+}
+
+func joinFixedWidth(fields map[string]string) string {
+	var line strings.Builder
+	for _, field := range fixedWidthLayout {
+		value := []rune(fields[field.name])
+		if len(value) > field.width {
+			value = value[:field.width]
+		}
+		line.WriteString(string(value))
+		line.WriteString(strings.Repeat(" ", field.width-len(value)))
+	}
+	return line.String()
+}
+
+type fixedWidthFormat struct{}
+
+func (fixedWidthFormat) Name() string {
+	return "fixed-width"
+}
+
+func (fixedWidthFormat) Detect(head []byte) bool {
+	line := firstLine(head)
+	return strings.HasPrefix(line, fixedWidthTag) && len([]rune(line)) >= fixedWidthMinLength()
+}
+
+// Read skips records flagged as deleted, they are no longer part of the
+// directory.
+func (fixedWidthFormat) Read(in io.Reader) ([]*DirectoryRows, error) {
+	rows := []*DirectoryRows{}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		fields := splitFixedWidth(text)
+		if fields["TAG"] != fixedWidthTag {
+			return nil, fmt.Errorf("line %d: expected a %s record", line, fixedWidthTag)
+		}
+		if fields["MODIFICATION FLAG"] == fixedWidthDeleted {
+			continue
+		}
+
+		var address []string
+		for _, name := range fixedWidthAddress {
+			if fields[name] != "" {
+				address = append(address, fields[name])
+			}
+		}
+		swiftCode := fields["BIC CODE"] + fields["BRANCH CODE"]
+		countryISO2Code := ""
+		if len(swiftCode) >= 6 {
+			countryISO2Code = swiftCode[4:6]
+		}
+
+		rows = append(rows, &DirectoryRows{
+			SwiftCodes: services.SwiftCodes{
+				SwiftCode:       swiftCode,
+				CountryISO2Code: countryISO2Code,
+				CodeType:        "BIC11",
+				BankName:        fields["INSTITUTION NAME"],
+				Address:         strings.Join(address, " "),
+				TownName:        fields["CITY HEADING"],
+				CountryName:     fields["COUNTRY NAME"],
+			},
+			Line: line,
+		})
+	}
+	return rows, scanner.Err()
+}
+
+// Write leaves out time zones and effective dates, the layout has no place
+// for them.
+func (fixedWidthFormat) Write(out io.Writer, swiftCodes []services.SwiftCodes) error {
+	w := bufio.NewWriter(out)
+	for _, swiftCode := range swiftCodes {
+		fields := map[string]string{
+			"TAG":              fixedWidthTag,
+			"INSTITUTION NAME": swiftCode.BankName,
+			"CITY HEADING":     swiftCode.TownName,
+			"COUNTRY NAME":     swiftCode.CountryName,
+		}
+		if len(swiftCode.SwiftCode) == 11 {
+			fields["BIC CODE"] = swiftCode.SwiftCode[:8]
+			fields["BRANCH CODE"] = swiftCode.SwiftCode[8:]
+		}
+		for i, line := range wrapWords(swiftCode.Address, 35) {
+			if i < len(fixedWidthAddress) {
+				fields[fixedWidthAddress[i]] = line
+			}
+		}
+		if _, err := fmt.Fprintln(w, joinFixedWidth(fields)); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
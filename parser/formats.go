@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-mongo-app/services"
+)
+
+// detectBytes is how much of a file formats get to recognise it.
+const detectBytes = 4096
+
+// Format reads and writes one layout of directory files.
+type Format interface {
+	Name() string
+	// Detect reports whether a file starting with head is in this format.
+	Detect(head []byte) bool
+	Read(in io.Reader) ([]*DirectoryRows, error)
+	Write(out io.Writer, swiftCodes []services.SwiftCodes) error
+}
+
+// formats are tried in order when detecting the format of a file. The other
+// built-in CSV profiles are added by init.
+var formats = []Format{csvFormat{profile: builtInProfiles[0]}, fixedWidthFormat{}}
+
+// RegisterFormat adds a format, replacing a known one with the same name.
+func RegisterFormat(format Format) {
+	for i, known := range formats {
+		if known.Name() == format.Name() {
+			formats[i] = format
+			return
+		}
+	}
+	formats = append(formats, format)
+}
+
+func FormatNames() []string {
+	names := make([]string, 0, len(formats))
+	for _, format := range formats {
+		names = append(names, format.Name())
+	}
+	return names
+}
+
+func FormatByName(name string) (Format, error) {
+	for _, format := range formats {
+		if format.Name() == name {
+			return format, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown format %q, expected one of %s", name, strings.Join(FormatNames(), ", "))
+}
+
+func DetectFormat(head []byte) (Format, error) {
+	head = bytes.TrimPrefix(head, []byte("\ufeff"))
+	for _, format := range formats {
+		if format.Detect(head) {
+			return format, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognised directory file format")
+}
+
+// ReadRows reads a directory file in the named format, or in the detected
+// one when formatName is empty.
+func ReadRows(in io.Reader, formatName string) (Format, []*DirectoryRows, error) {
+	reader := bufio.NewReaderSize(in, detectBytes)
+
+	var format Format
+	var err error
+	if formatName != "" {
+		format, err = FormatByName(formatName)
+	} else {
+		head, _ := reader.Peek(detectBytes)
+		format, err = DetectFormat(head)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := format.Read(reader)
+	if err != nil {
+		return format, nil, fmt.Errorf("%s: %w", format.Name(), err)
+	}
+	return format, rows, nil
+}
+
+// firstLine returns the first non-empty line of head.
+func firstLine(head []byte) string {
+	for _, line := range strings.Split(string(head), "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// wrapWords splits text into lines of at most width runes, breaking at
+// spaces. Joining the lines with a space gives text back unless a single
+// word is longer than width.
+func wrapWords(text string, width int) []string {
+	var lines []string
+	line := []rune{}
+	for i, word := range strings.Split(text, " ") {
+		runes := []rune(word)
+		if i > 0 {
+			if len(line)+1+len(runes) > width {
+				lines = append(lines, string(line))
+				line = []rune{}
+			} else {
+				line = append(line, ' ')
+			}
+		}
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		line = append(line, runes...)
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
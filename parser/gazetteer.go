@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-mongo-app/services"
+)
+
+// gazetteerColumns are the columns of gazetteer.csv, coordinates are in
+// decimal degrees.
+var gazetteerColumns = []string{"COUNTRY ISO2 CODE", "TOWN NAME", "LATITUDE", "LONGITUDE"}
+
+// ReadPlaces parses a gazetteer: a CSV file with the gazetteerColumns in any
+// order.
+func ReadPlaces(in io.Reader) ([]services.Places, error) {
+	places := []services.Places{}
+	err := readTable(in, "gazetteer", gazetteerColumns, func(row tableRows) error {
+		place := services.Places{
+			CountryISO2Code: strings.ToUpper(row.Value("COUNTRY ISO2 CODE")),
+			TownName:        strings.ToUpper(row.Value("TOWN NAME")),
+		}
+		if _, ok := services.LookupCountry(place.CountryISO2Code); !ok {
+			return fmt.Errorf("%s is not an ISO 3166-1 country code", place.CountryISO2Code)
+		}
+		if place.TownName == "" {
+			return fmt.Errorf("town name can't be empty")
+		}
+		var err error
+		if place.Latitude, err = strconv.ParseFloat(row.Value("LATITUDE"), 64); err != nil {
+			return fmt.Errorf("invalid latitude %q", row.Value("LATITUDE"))
+		}
+		if place.Longitude, err = strconv.ParseFloat(row.Value("LONGITUDE"), 64); err != nil {
+			return fmt.Errorf("invalid longitude %q", row.Value("LONGITUDE"))
+		}
+		if err = services.ValidateCoordinates(place.Latitude, place.Longitude); err != nil {
+			return err
+		}
+		places = append(places, place)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+// ImportGazetteerFile reads a gazetteer for the swift codes in
+// collectionName and locates them.
+func ImportGazetteerFile(ctx context.Context, path string, collectionName string) (int, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	places, err := ReadPlaces(in)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	var swiftCode services.SwiftCodes
+	return swiftCode.ImportPlaces(ctx, places, collectionName)
+}
+
+// ParseGazetteerToMongoDatabase imports gazetteer.csv at startup.
+func ParseGazetteerToMongoDatabase() error {
+	return importTableFile("gazetteer.csv", "places", func(ctx context.Context, path string) (int, error) {
+		return ImportGazetteerFile(ctx, path, "swift_codes")
+	})
+}
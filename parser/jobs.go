@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-mongo-app/services"
+)
+
+// progressInterval is the number of rows imported between progress updates.
+const progressInterval = 100
+
+// cancelPollInterval is how often a running job checks whether it was
+// cancelled.
+const cancelPollInterval = time.Second
+
+// jobLock runs one import job at a time, so jobs importing the same codes
+// don't race each other.
+var jobLock sync.Mutex
+
+// RunImportJob imports the file uploaded for a job into collectionName. A
+// job interrupted by a restart continues after its last saved row. When ctx
+// is cancelled the job is left running, to be resumed later.
+func RunImportJob(ctx context.Context, id string, jobsCollectionName string, collectionName string) error {
+	jobLock.Lock()
+	defer jobLock.Unlock()
+
+	var jobs services.ImportJobs
+	job, err := jobs.StartImportJob(id, jobsCollectionName)
+	if err != nil {
+		return err
+	}
+	log.Printf("import job %s: importing %s", job.ID, job.FileName)
+
+	fail := func(err error) error {
+		log.Printf("import job %s: %v", job.ID, err)
+		jobs.FinishImportJob(job.ID, services.ImportFailed, err, jobsCollectionName)
+		return err
+	}
+
+	// cancelled is done once the job was asked to stop; ctx only stops the
+	// process, which leaves the job running.
+	cancelled, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	go watchCancellation(cancelled, stopWatching, job.ID, jobsCollectionName)
+
+	payload, err := jobs.ReadImportPayload(job.ID, jobsCollectionName)
+	if err != nil {
+		return fail(err)
+	}
+	format, rows, err := ReadRows(bytes.NewReader(payload), job.Format)
+	if err != nil {
+		return fail(err)
+	}
+
+	var names []string
+	for _, row := range rows {
+		names = append(names, row.SwiftCode)
+	}
+	headquarters := headquartersIn(names)
+
+	progress := job.Progress()
+	progress.Format = format.Name()
+	progress.TotalRows = len(rows)
+	ctx = services.WithAuditContext(ctx, services.AuditContext{Actor: job.Actor, RequestID: job.RequestID})
+	cancel := func() error {
+		log.Printf("import job %s: cancelled after %d rows", job.ID, progress.ProcessedRows)
+		return jobs.FinishImportJob(job.ID, services.ImportCancelled, nil, jobsCollectionName)
+	}
+	for i := progress.ProcessedRows; i < len(rows); i++ {
+		if ctx.Err() != nil {
+			jobs.UpdateImportProgress(job.ID, progress, jobsCollectionName)
+			return ctx.Err()
+		}
+		if cancelled.Err() != nil {
+			if _, err = jobs.UpdateImportProgress(job.ID, progress, jobsCollectionName); err != nil {
+				return fail(err)
+			}
+			return cancel()
+		}
+
+		row := rows[i]
+		if swiftCode, err := row.ToSwiftCode(); err != nil {
+			progress.Reject(row.Line, row.SwiftCode, err.Error())
+		} else if imported, orphan, err := importSwiftCode(ctx, swiftCode, hasValidity(rows), headquarters, collectionName); err != nil {
+			progress.Reject(row.Line, row.SwiftCode, err.Error())
+		} else {
+			if imported {
+				progress.ImportedRows++
+			} else {
+				progress.SkippedRows++
+			}
+			if orphan {
+				progress.OrphanCount++
+			}
+		}
+		progress.ProcessedRows = i + 1
+
+		if progress.ProcessedRows%progressInterval == 0 {
+			cancelRequested, err := jobs.UpdateImportProgress(job.ID, progress, jobsCollectionName)
+			if err != nil {
+				return fail(err)
+			}
+			if cancelRequested {
+				return cancel()
+			}
+		}
+	}
+
+	cancelRequested, err := jobs.UpdateImportProgress(job.ID, progress, jobsCollectionName)
+	if err != nil {
+		return fail(err)
+	}
+	if cancelRequested {
+		return cancel()
+	}
+	log.Printf("import job %s: %d imported, %d skipped, %d rejected, %d without headquarter", job.ID, progress.ImportedRows, progress.SkippedRows, progress.RejectedCount, progress.OrphanCount)
+	return jobs.FinishImportJob(job.ID, services.ImportSucceeded, nil, jobsCollectionName)
+}
+
+// watchCancellation calls stop once the job was asked to stop, checking
+// every cancelPollInterval until done is done.
+func watchCancellation(done context.Context, stop context.CancelFunc, id string, jobsCollectionName string) {
+	var jobs services.ImportJobs
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done.Done():
+			return
+		case <-ticker.C:
+			job, err := jobs.GetImportJob(id, jobsCollectionName)
+			if err == nil && job.CancelRequested {
+				stop()
+				return
+			}
+		}
+	}
+}
+
+// ResumeImportJobs runs the jobs left queued or running by a previous
+// process, oldest first.
+func ResumeImportJobs(ctx context.Context, jobsCollectionName string, collectionName string) {
+	var jobs services.ImportJobs
+	unfinished, err := jobs.GetUnfinishedImportJobs(jobsCollectionName)
+	if err != nil {
+		return
+	}
+	for _, job := range unfinished {
+		if job.CancelRequested {
+			jobs.FinishImportJob(job.ID, services.ImportCancelled, nil, jobsCollectionName)
+			continue
+		}
+		RunImportJob(ctx, job.ID, jobsCollectionName, collectionName)
+	}
+}
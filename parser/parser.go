@@ -1,13 +1,142 @@
 package parser
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/go-mongo-app/services"
-	"github.com/gocarina/gocsv"
 )
 
+// DirectoryRows are entries of a directory file as read by a Format, before
+// they are validated. ValidFrom and ValidTo schedule codes which come into or
+// go out of effect later.
+type DirectoryRows struct {
+	services.SwiftCodes
+	ValidFrom string
+	ValidTo   string
+	// HasValidity tells whether the file has validity columns at all.
+	// Empty dates in a file without them don't clear the stored ones.
+	HasValidity bool
+	// Line is the line of the row in its file.
+	Line int
+}
+
+func parseValidity(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := services.ParseDate(value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// formatValidity writes dates at midnight UTC as plain dates.
+func formatValidity(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	if utc := t.UTC(); utc.Equal(utc.Truncate(24 * time.Hour)) {
+		return utc.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ToSwiftCode turns a row into a swift code ready to be stored.
+func (row DirectoryRows) ToSwiftCode() (services.SwiftCodes, error) {
+	validFrom, err := parseValidity(row.ValidFrom)
+	if err != nil {
+		return services.SwiftCodes{}, fmt.Errorf("invalid valid from date %q", row.ValidFrom)
+	}
+	validTo, err := parseValidity(row.ValidTo)
+	if err != nil {
+		return services.SwiftCodes{}, fmt.Errorf("invalid valid to date %q", row.ValidTo)
+	}
+	if len(row.SwiftCode) != 11 {
+		return services.SwiftCodes{}, fmt.Errorf("swift code must be exactly 11 characters")
+	}
+	countryISO2Code := strings.ToUpper(row.CountryISO2Code)
+	countryName, err := services.ValidateCountry(countryISO2Code, row.CountryName)
+	if err != nil {
+		return services.SwiftCodes{}, err
+	}
+	timeZone, err := services.ValidateTimeZone(row.TimeZone, countryISO2Code)
+	if err != nil {
+		return services.SwiftCodes{}, err
+	}
+
+	return services.SwiftCodes{
+		SwiftCode:       row.SwiftCode,
+		CountryISO2Code: countryISO2Code,
+		CodeType:        row.CodeType,
+		BankName:        row.BankName,
+		Address:         row.Address,
+		TownName:        row.TownName,
+		CountryName:     countryName,
+		TimeZone:        timeZone,
+		IsHeadQuater:    strings.HasSuffix(row.SwiftCode, "XXX"),
+		ValidFrom:       validFrom,
+		ValidTo:         validTo,
+	}, nil
+}
+
+// ReadDirectory parses a directory file in the named format, or in the
+// detected one when formatName is empty, into swift codes ready to be stored.
+// It fails on the first invalid row.
+func ReadDirectory(in io.Reader, formatName string) ([]services.SwiftCodes, error) {
+	_, rows, err := ReadRows(in, formatName)
+	if err != nil {
+		return nil, err
+	}
+
+	swiftCodes := make([]services.SwiftCodes, 0, len(rows))
+	for _, row := range rows {
+		swiftCode, err := row.ToSwiftCode()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row.Line, err)
+		}
+		swiftCodes = append(swiftCodes, swiftCode)
+	}
+	return swiftCodes, nil
+}
+
+func ReadDirectoryFile(path string, formatName string) ([]services.SwiftCodes, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return ReadDirectory(in, formatName)
+}
+
+// OrphanPolicy is applied to imported branches whose headquarter is in
+// neither the file nor the collection.
+var OrphanPolicy = services.OrphanFlag
+
+// headquartersIn collects the prefixes of the headquarters in a file. They
+// count as present for the branches of the file even when their rows come
+// later.
+func headquartersIn(swiftCodes []string) map[string]bool {
+	headquarters := map[string]bool{}
+	for _, swiftCode := range swiftCodes {
+		if len(swiftCode) == 11 && strings.HasSuffix(swiftCode, "XXX") {
+			headquarters[swiftCode[:8]] = true
+		}
+	}
+	return headquarters
+}
+
+// hasValidity tells whether rows come from a file with validity columns.
+func hasValidity(rows []*DirectoryRows) bool {
+	return len(rows) > 0 && rows[0].HasValidity
+}
+
 func ParseCSVToMongoDatabase() error {
 	in, err := os.Open("swift_codes.csv")
 	if err != nil {
@@ -15,36 +144,80 @@ func ParseCSVToMongoDatabase() error {
 		return err
 	}
 	defer in.Close()
-
-	swiftCodes := []*services.SwiftCodes{}
-
-	if err := gocsv.UnmarshalFile(in, &swiftCodes); err != nil {
+	_, rows, err := ReadRows(in, "")
+	if err != nil {
 		log.Fatal(err)
 		return err
 	}
-	var swfiCodeDb services.SwiftCodes
+	swiftCodes := make([]services.SwiftCodes, 0, len(rows))
+	for _, row := range rows {
+		swiftCode, err := row.ToSwiftCode()
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", row.Line, err)
+			log.Fatal(err)
+			return err
+		}
+		swiftCodes = append(swiftCodes, swiftCode)
+	}
+
+	var names []string
+	for _, swiftCode := range swiftCodes {
+		names = append(names, swiftCode.SwiftCode)
+	}
+	headquarters := headquartersIn(names)
+
 	collection_name := "swift_codes"
+	ctx := services.WithAuditContext(context.Background(), services.AuditContext{Actor: "system:csv-import"})
+	orphans := 0
 	for _, swiftCode := range swiftCodes {
-		if swfiCodeDb.IsSwiftCodeInDatabase(swiftCode.SwiftCode, collection_name) {
-			continue
+		if _, orphan, err := importSwiftCode(ctx, swiftCode, hasValidity(rows), headquarters, collection_name); orphan {
+			orphans++
+			if err != nil {
+				log.Printf("%s not imported: %v", swiftCode.SwiftCode, err)
+			}
 		}
-		isHeadQUater := false
-		if swiftCode.SwiftCode[len(swiftCode.SwiftCode)-3:] == "XXX" {
-			isHeadQUater = true
-		}
-		swfiCodeDb.InsertSwiftCode(services.SwiftCodes{
-			SwiftCode:       swiftCode.SwiftCode,
-			CountryISO2Code: swiftCode.CountryISO2Code,
-			CodeType:        swiftCode.CodeType,
-			BankName:        swiftCode.BankName,
-			Address:         swiftCode.Address,
-			TownName:        swiftCode.TownName,
-			CountryName:     swiftCode.CountryName,
-			TimeZone:        swiftCode.TimeZone,
-			IsHeadQuater:    isHeadQUater,
-		}, collection_name)
-
+	}
+	if orphans > 0 {
+		log.Printf("swift_codes.csv: %d branches without headquarter, policy %s", orphans, OrphanPolicy)
 	}
 
 	return nil
 }
+
+// importSwiftCode stores a code unless it is already known and reports
+// whether anything changed and whether the code is a branch whose
+// headquarter is missing, which OrphanPolicy decides about. Known codes only
+// pick up newly scheduled changes, e.g. an announced removal, when the file
+// has validity columns, and deleted codes stay deleted. A placeholder
+// headquarter takes the data of the real one.
+func importSwiftCode(ctx context.Context, swiftCode services.SwiftCodes, withValidity bool, headquarters map[string]bool, collectionName string) (bool, bool, error) {
+	var swfiCodeDb services.SwiftCodes
+	if swfiCodeDb.IsSwiftCodeDeleted(swiftCode.SwiftCode, collectionName) {
+		return false, false, nil
+	}
+	if swfiCodeDb.IsSwiftCodeInDatabase(swiftCode.SwiftCode, collectionName) {
+		stored, err := swfiCodeDb.GetSwiftCodeBySwiftCodeName(swiftCode.SwiftCode, collectionName)
+		if err != nil {
+			return false, false, err
+		}
+		if stored.Placeholder && !swiftCode.Placeholder {
+			err = swfiCodeDb.ReplacePlaceholder(ctx, swiftCode, collectionName)
+			return err == nil, false, err
+		}
+		if !withValidity || services.SameTime(stored.ValidFrom, swiftCode.ValidFrom) && services.SameTime(stored.ValidTo, swiftCode.ValidTo) {
+			return false, false, nil
+		}
+		err = swfiCodeDb.SetSwiftCodeValidity(ctx, swiftCode.SwiftCode, swiftCode.ValidFrom, swiftCode.ValidTo, services.OperationImport, collectionName)
+		return err == nil, false, err
+	}
+
+	orphan := false
+	if !swiftCode.IsHeadQuater && !headquarters[swiftCode.SwiftCode[:8]] {
+		var err error
+		if orphan, err = swfiCodeDb.CheckHeadquarter(ctx, swiftCode, OrphanPolicy, collectionName); err != nil {
+			return false, orphan, err
+		}
+	}
+	err := swfiCodeDb.ImportSwiftCode(ctx, swiftCode, collectionName)
+	return err == nil, orphan, err
+}
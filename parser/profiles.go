@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const (
+	QuotingStandard = "standard"
+	// QuotingLazy accepts quotes inside unquoted fields and stray quotes in
+	// quoted ones.
+	QuotingLazy = "lazy"
+	// QuotingNone treats quotes as ordinary characters.
+	QuotingNone = "none"
+)
+
+// CSVProfiles describe the dialect and columns of a CSV export. Columns maps
+// swift code fields, named like their json keys, to the header names of the
+// columns holding them, or to 1-based column numbers for files without a
+// header. A field spread over several columns, e.g. address lines, is joined
+// with spaces.
+type CSVProfiles struct {
+	Name      string              `json:"name"`
+	Delimiter string              `json:"delimiter"`
+	Quoting   string              `json:"quoting"`
+	Encoding  string              `json:"encoding"`
+	HasHeader bool                `json:"hasheader"`
+	Columns   map[string][]string `json:"columns"`
+}
+
+// profileFields are the fields a profile can map.
+var profileFields = []string{"countryiso2code", "swiftcode", "codetype", "bankname", "address", "townname", "countryname", "timezone", "validfrom", "validto"}
+
+// directoryColumns are the columns of swift_codes.csv.
+var directoryColumns = map[string][]string{
+	"countryiso2code": {"COUNTRY ISO2 CODE"},
+	"swiftcode":       {"SWIFT CODE"},
+	"codetype":        {"CODE TYPE"},
+	"bankname":        {"NAME"},
+	"address":         {"ADDRESS"},
+	"townname":        {"TOWN NAME"},
+	"countryname":     {"COUNTRY NAME"},
+	"timezone":        {"TIME ZONE"},
+	"validfrom":       {"VALID FROM"},
+	"validto":         {"VALID TO"},
+}
+
+// builtInProfiles cover swift_codes.csv and common bank data exports. The
+// first one is the default csv format.
+var builtInProfiles = []CSVProfiles{
+	{
+		Name:      "csv",
+		Delimiter: ",",
+		Encoding:  "utf-8",
+		HasHeader: true,
+		Columns:   directoryColumns,
+	},
+	{
+		// Spreadsheets saved with a European locale.
+		Name:      "excel-semicolon",
+		Delimiter: ";",
+		Encoding:  "windows-1252",
+		HasHeader: true,
+		Columns:   directoryColumns,
+	},
+	{
+		// swift_codes.csv without its header line.
+		Name:      "headerless",
+		Delimiter: ",",
+		Encoding:  "utf-8",
+		Columns: map[string][]string{
+			"countryiso2code": {"1"},
+			"swiftcode":       {"2"},
+			"codetype":        {"3"},
+			"bankname":        {"4"},
+			"address":         {"5"},
+			"townname":        {"6"},
+			"countryname":     {"7"},
+			"timezone":        {"8"},
+		},
+	},
+	{
+		// Tab separated directory exports with one column per address
+		// line.
+		Name:      "tab-separated",
+		Delimiter: "\t",
+		Quoting:   QuotingNone,
+		Encoding:  "utf-8",
+		HasHeader: true,
+		Columns: map[string][]string{
+			"swiftcode":       {"BIC"},
+			"bankname":        {"INSTITUTION NAME"},
+			"address":         {"STREET ADDRESS 1", "STREET ADDRESS 2", "STREET ADDRESS 3", "STREET ADDRESS 4"},
+			"townname":        {"CITY"},
+			"countryname":     {"COUNTRY NAME"},
+			"countryiso2code": {"ISO COUNTRY CODE"},
+		},
+	},
+}
+
+func init() {
+	for _, profile := range builtInProfiles {
+		RegisterFormat(csvFormat{profile: profile})
+	}
+}
+
+// Validate checks a profile before it is used.
+func (p CSVProfiles) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("profile name can't be empty")
+	}
+	if _, err := p.delimiter(); err != nil {
+		return fmt.Errorf("profile %s: %w", p.Name, err)
+	}
+	switch p.Quoting {
+	case "", QuotingStandard, QuotingLazy, QuotingNone:
+	default:
+		return fmt.Errorf("profile %s: unknown quoting %q", p.Name, p.Quoting)
+	}
+	if _, err := p.encoding(); err != nil {
+		return fmt.Errorf("profile %s: %w", p.Name, err)
+	}
+	if len(p.Columns["swiftcode"]) == 0 {
+		return fmt.Errorf("profile %s: swiftcode column is required", p.Name)
+	}
+	for field, columns := range p.Columns {
+		if !isProfileField(field) {
+			return fmt.Errorf("profile %s: unknown field %q", p.Name, field)
+		}
+		if p.HasHeader {
+			continue
+		}
+		for _, column := range columns {
+			if n, err := strconv.Atoi(column); err != nil || n < 1 {
+				return fmt.Errorf("profile %s: column %q of %s must be a number, the file has no header", p.Name, column, field)
+			}
+		}
+	}
+	return nil
+}
+
+func isProfileField(field string) bool {
+	for _, known := range profileFields {
+		if known == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (p CSVProfiles) delimiter() (rune, error) {
+	if p.Delimiter == "" {
+		return ',', nil
+	}
+	runes := []rune(p.Delimiter)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+		return 0, fmt.Errorf("delimiter must be a single character other than a quote or line break")
+	}
+	return runes[0], nil
+}
+
+func (p CSVProfiles) encoding() (encoding.Encoding, error) {
+	if p.Encoding == "" {
+		return encoding.Nop, nil
+	}
+	enc, err := htmlindex.Get(p.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", p.Encoding)
+	}
+	return enc, nil
+}
+
+// LoadProfiles reads a JSON array of profiles and registers them as formats
+// next to the built-in ones.
+func LoadProfiles(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var profiles []CSVProfiles
+	if err = json.Unmarshal(content, &profiles); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, profile := range profiles {
+		if err = RegisterProfile(profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RegisterProfile(profile CSVProfiles) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	RegisterFormat(csvFormat{profile: profile})
+	return nil
+}
+
+// ProfileNames lists the registered CSV profiles.
+func ProfileNames() []string {
+	var names []string
+	for _, format := range formats {
+		if _, ok := format.(csvFormat); ok {
+			names = append(names, format.Name())
+		}
+	}
+	return names
+}
+
+func normalizeHeader(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
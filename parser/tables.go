@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// tableRows are rows of a CSV table, their values looked up by column name.
+type tableRows struct {
+	Line    int
+	columns map[string]int
+	record  []string
+}
+
+// Value returns the trimmed value of column name, empty when the table or
+// the row doesn't have it.
+func (r tableRows) Value(name string) string {
+	if i, ok := r.columns[name]; ok && i < len(r.record) {
+		return strings.TrimSpace(r.record[i])
+	}
+	return ""
+}
+
+// Has tells whether the table has column name.
+func (r tableRows) Has(name string) bool {
+	_, ok := r.columns[name]
+	return ok
+}
+
+// readTable reads a CSV table named table, e.g. "gazetteer", whose header
+// has the required columns in any order, and hands every row to read. It
+// fails on the first row read refuses, naming its line.
+func readTable(in io.Reader, table string, required []string, read func(row tableRows) error) error {
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s has no header: %w", table, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[normalizeHeader(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("%s has no %s column", table, name)
+		}
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = read(tableRows{Line: line, columns: columns, record: record}); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// importTableFile imports the table in path at startup when it lies next to
+// swift_codes.csv. what names the imported rows in the log.
+func importTableFile(path string, what string, importFile func(ctx context.Context, path string) (int, error)) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	imported, err := importFile(context.Background(), path)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	log.Printf("%s: %d %s imported", path, imported, what)
+	return nil
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-mongo-app/services"
+)
+
+const (
+	processedDir = "processed"
+	failedDir    = "failed"
+)
+
+// Watcher imports directory files dropped into Dir. Each file goes through
+// an import job like an upload, then moves to the processed or failed
+// subfolder and its outcome is recorded in FilesCollectionName.
+type Watcher struct {
+	Dir      string
+	Interval time.Duration
+	// Settle is how long a file must stay unmodified before it is picked
+	// up, so files still being copied are left alone.
+	Settle time.Duration
+	// Format names the format of dropped files, detected when empty.
+	Format string
+
+	CollectionName      string
+	JobsCollectionName  string
+	FilesCollectionName string
+}
+
+// Run scans the directory every Interval until ctx is cancelled.
+func (w Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.Scan(ctx); err != nil {
+			log.Println("watcher:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan imports every settled file in the directory once.
+func (w Watcher) Scan(ctx context.Context) error {
+	entries, err := os.ReadDir(w.Dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < w.Settle {
+			continue
+		}
+		if err = w.processFile(ctx, entry.Name()); err != nil {
+			log.Printf("watcher: %s: %v", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (w Watcher) processFile(ctx context.Context, name string) error {
+	var watchedFile services.WatchedFiles
+	path := filepath.Join(w.Dir, name)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	checksum := sha256.Sum256(content)
+
+	outcome := services.WatchedFiles{
+		FileName: name,
+		Checksum: hex.EncodeToString(checksum[:]),
+		Size:     int64(len(content)),
+	}
+	if watchedFile.IsChecksumImported(outcome.Checksum, w.FilesCollectionName) {
+		outcome.Status = services.WatchedDuplicate
+	} else {
+		outcome.Status, outcome.JobID, err = w.importFile(ctx, name, content)
+		if err != nil {
+			outcome.Error = err.Error()
+		}
+	}
+	if ctx.Err() != nil {
+		// Stopped mid import; the job resumes with the next start and the
+		// file stays for the next scan.
+		return ctx.Err()
+	}
+
+	dir := processedDir
+	if outcome.Status == services.WatchedFailed {
+		dir = failedDir
+	}
+	if err = os.MkdirAll(filepath.Join(w.Dir, dir), 0o755); err != nil {
+		return err
+	}
+	outcome.MovedTo = filepath.Join(dir, time.Now().UTC().Format("20060102T150405Z")+"-"+name)
+	if err = os.Rename(path, filepath.Join(w.Dir, outcome.MovedTo)); err != nil {
+		return err
+	}
+	log.Printf("watcher: %s %s, moved to %s", name, outcome.Status, outcome.MovedTo)
+	return watchedFile.RecordWatchedFile(outcome, w.FilesCollectionName)
+}
+
+func (w Watcher) importFile(ctx context.Context, name string, content []byte) (string, string, error) {
+	var jobs services.ImportJobs
+	ctx = services.WithAuditContext(ctx, services.AuditContext{Actor: "system:watcher"})
+	job, err := jobs.CreateImportJob(ctx, name, w.Format, bytes.NewReader(content), w.JobsCollectionName)
+	if err != nil {
+		return services.WatchedFailed, "", err
+	}
+
+	RunImportJob(ctx, job.ID, w.JobsCollectionName, w.CollectionName)
+	job, err = jobs.GetImportJob(job.ID, w.JobsCollectionName)
+	if err != nil {
+		return services.WatchedFailed, job.ID, err
+	}
+	if job.Status != services.ImportSucceeded {
+		return services.WatchedFailed, job.ID, fmt.Errorf("import job %s %s", job.Status, job.Error)
+	}
+	return services.WatchedImported, job.ID, nil
+}
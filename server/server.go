@@ -0,0 +1,188 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-mongo-app/config"
+)
+
+// CertReloader serves the certificate, key and client CA pool most recently
+// read from disk, so certificates can be rotated without a restart.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+func NewCertReloader(certFile string, keyFile string, caFile string) (*CertReloader, error) {
+	reloader := &CertReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (c *CertReloader) files() []string {
+	files := []string{c.certFile, c.keyFile}
+	if c.caFile != "" {
+		files = append(files, c.caFile)
+	}
+	return files
+}
+
+func (c *CertReloader) load() error {
+	modTimes := map[string]time.Time{}
+	for _, file := range c.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	var caPool *x509.CertPool
+	if c.caFile != "" {
+		pem, err := os.ReadFile(c.caFile)
+		if err != nil {
+			return err
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", c.caFile)
+		}
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.caPool = caPool
+	c.modTimes = modTimes
+	c.mu.Unlock()
+	return nil
+}
+
+// ReloadIfChanged reads the files again when any of them was modified. On
+// error the previously loaded certificates stay in use.
+func (c *CertReloader) ReloadIfChanged() (bool, error) {
+	c.mu.RLock()
+	changed := false
+	for _, file := range c.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			c.mu.RUnlock()
+			return false, err
+		}
+		if !info.ModTime().Equal(c.modTimes[file]) {
+			changed = true
+		}
+	}
+	c.mu.RUnlock()
+
+	if !changed {
+		return false, nil
+	}
+	if err := c.load(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *CertReloader) Watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		reloaded, err := c.ReloadIfChanged()
+		if err != nil {
+			log.Println("Error while reloading TLS certificates", err)
+		} else if reloaded {
+			log.Println("Reloaded TLS certificates")
+		}
+	}
+}
+
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// TLSConfig builds a config which picks up reloaded certificates on every
+// handshake. clientAuth is only used when a client CA file is set.
+func (c *CertReloader) TLSConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.GetCertificate,
+	}
+	if c.caFile == "" {
+		return base
+	}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: c.GetCertificate,
+			ClientAuth:     clientAuth,
+			ClientCAs:      c.caPool,
+		}, nil
+	}
+	return base
+}
+
+func parseClientAuth(clientAuth string) (tls.ClientAuthType, error) {
+	switch clientAuth {
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("TLS client auth must be \"require\" or \"optional\"")
+}
+
+func ListenAndServe(cfg config.Server, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		log.Println("Server running on", cfg.Addr)
+		return srv.ListenAndServe()
+	}
+
+	clientAuth, err := parseClientAuth(cfg.ClientAuth)
+	if err != nil {
+		return err
+	}
+	reloader, err := NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	if cfg.ReloadInterval > 0 {
+		go reloader.Watch(cfg.ReloadInterval)
+	}
+
+	srv.TLSConfig = reloader.TLSConfig(clientAuth)
+	if cfg.ClientCAFile != "" {
+		log.Println("Server running with mutual TLS on", cfg.Addr)
+	} else {
+		log.Println("Server running with TLS on", cfg.Addr)
+	}
+	return srv.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// PostalAddresses are addresses split into the fields of an ISO 20022
+// structured postal address. They are parsed from the raw address, which is
+// kept as it came, see ParseAddress.
+type PostalAddresses struct {
+	StreetName         string `json:"streetname,omitempty" bson:"_streetname,omitempty"`
+	BuildingNumber     string `json:"buildingnumber,omitempty" bson:"_buildingnumber,omitempty"`
+	PostCode           string `json:"postcode,omitempty" bson:"_postcode,omitempty"`
+	TownName           string `json:"townname,omitempty" bson:"_townname,omitempty"`
+	CountrySubDivision string `json:"countrysubdivision,omitempty" bson:"_countrysubdivision,omitempty"`
+	Country            string `json:"country" bson:"_country"`
+}
+
+var (
+	hasDigit       = regexp.MustCompile(`[0-9]`)
+	buildingNumber = regexp.MustCompile(`^[0-9]+[A-Z]?([/-][0-9]+[A-Z]?)?$`)
+	// unitWords are followed by numbers which aren't building numbers.
+	unitWords = map[string]bool{"FLOOR": true, "FL.": true, "LEVEL": true, "SUITE": true, "LOK.": true, "APT.": true}
+)
+
+// ParseAddress splits a directory address of the form
+// "STREET BUILDING TOWN, SUBDIVISION, POSTCODE" into its fields. The last
+// part is the post code when it has a digit, the part before it the country
+// subdivision, and the town is cut off the end of the rest. The building
+// number is the last word of the street when it is a number not following
+// a floor or suite, or else the first one. Whitespace is collapsed and
+// letters upper cased. Parts which can't be told apart stay in the street
+// name.
+func ParseAddress(address string, townName string, isoCode string) *PostalAddresses {
+	postalAddress := &PostalAddresses{
+		TownName: normalizeAddress(townName),
+		Country:  strings.ToUpper(isoCode),
+	}
+
+	parts := strings.Split(normalizeAddress(address), ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) > 1 && hasDigit.MatchString(parts[len(parts)-1]) {
+		postalAddress.PostCode = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) > 1 {
+		postalAddress.CountrySubDivision = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	street := strings.Join(parts, ", ")
+
+	if postalAddress.TownName != "" {
+		for _, town := range []string{postalAddress.TownName, strings.ReplaceAll(postalAddress.TownName, ".", "")} {
+			if street == town {
+				street = ""
+				break
+			}
+			if strings.HasSuffix(street, " "+town) {
+				street = strings.TrimSuffix(street, " "+town)
+				break
+			}
+		}
+	} else if postalAddress.CountrySubDivision != "" {
+		// Without a town name the subdivision usually repeats the town.
+		postalAddress.TownName = postalAddress.CountrySubDivision
+	}
+	street = strings.TrimRight(street, " ,-")
+
+	words := strings.Fields(street)
+	switch {
+	case len(words) > 1 && buildingNumber.MatchString(words[len(words)-1]) && !unitWords[words[len(words)-2]]:
+		postalAddress.BuildingNumber = words[len(words)-1]
+		words = words[:len(words)-1]
+	case len(words) > 1 && buildingNumber.MatchString(words[0]) && !hasDigit.MatchString(words[1]):
+		postalAddress.BuildingNumber = words[0]
+		words = words[1:]
+	}
+	postalAddress.StreetName = strings.TrimRight(strings.Join(words, " "), " ,")
+	return postalAddress
+}
+
+// withDerivedFields returns the code with the fields derived from the
+// directory data: its classification, structured address and search keys.
+func (s SwiftCodes) withDerivedFields() SwiftCodes {
+	s.Classification = ClassifySwiftCode(s.SwiftCode)
+	s.PostalAddress = ParseAddress(s.Address, s.TownName, s.CountryISO2Code)
+	s.BankNameKey = SearchKey(s.BankName)
+	s.TownNameKey = SearchKey(s.TownName)
+	s.BankNameWords = strings.Fields(s.BankNameKey)
+	s.TownNameWords = strings.Fields(s.TownNameKey)
+	return s
+}
+
+func sameDerivedFields(a SwiftCodes, b SwiftCodes) bool {
+	if a.PostalAddress == nil || b.PostalAddress == nil {
+		return false
+	}
+	return a.Classification == b.Classification && *a.PostalAddress == *b.PostalAddress &&
+		a.BankNameKey == b.BankNameKey && a.TownNameKey == b.TownNameKey &&
+		sameWords(a.BankNameWords, b.BankNameWords) && sameWords(a.TownNameWords, b.TownNameWords)
+}
+
+// UpdateDerivedFields derives the fields of the stored codes again, filling
+// them in for codes stored before they existed, which queries by those
+// fields would miss. Like locations they are derived data, so the codes keep
+// their version and no audit entry is written. It returns the number of
+// codes changed.
+func (t *SwiftCodes) UpdateDerivedFields(ctx context.Context, collectionName string) (int, error) {
+	collection := returnCollectionPointer(collectionName)
+	end, err := beginWrite(ctx, collectionName)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+
+	var swiftCodes []SwiftCodes
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	if err = cursor.All(ctx, &swiftCodes); err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	changed := 0
+	for _, swiftCode := range swiftCodes {
+		derived := swiftCode.withDerivedFields()
+		if sameDerivedFields(swiftCode, derived) {
+			continue
+		}
+		set := bson.M{
+			"_classification": derived.Classification,
+			"_postaladdress":  derived.PostalAddress,
+		}
+		setSearchKeys(set, derived.BankNameKey, derived.TownNameKey)
+		update := bson.M{"$set": set}
+		if _, err = collection.UpdateOne(ctx, bson.M{"_swiftcode": swiftCode.SwiftCode}, update); err != nil {
+			log.Println(err)
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
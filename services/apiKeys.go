@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-mongo-app/auth"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type ApiKeys struct {
+	Name      string     `json:"name" bson:"_name"`
+	KeyHash   string     `json:"-" bson:"_keyhash"`
+	KeyPrefix string     `json:"keyprefix" bson:"_keyprefix"`
+	Scope     auth.Scope `json:"scope" bson:"_scope"`
+	CreatedAt time.Time  `json:"createdat" bson:"_createdat"`
+	RevokedAt *time.Time `json:"revokedat,omitempty" bson:"_revokedat,omitempty"`
+}
+
+// CreateApiKey stores a new key under the given name and returns the raw key.
+// The raw key is not recoverable afterwards.
+func (k *ApiKeys) CreateApiKey(name string, scope auth.Scope, collectionName string) (string, error) {
+	collection := returnCollectionPointer(collectionName)
+
+	if name == "" {
+		return "", fmt.Errorf("api key name can't be empty")
+	}
+
+	if _, err := auth.ParseScope(string(scope)); err != nil {
+		return "", err
+	}
+
+	count, err := collection.CountDocuments(context.Background(), bson.M{"_name": name, "_revokedat": bson.M{"$exists": false}})
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+	if count != 0 {
+		return "", fmt.Errorf("active api key with such name exists")
+	}
+
+	rawKey, err := auth.GenerateApiKey()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = collection.InsertOne(context.Background(), ApiKeys{
+		Name:      name,
+		KeyHash:   auth.HashApiKey(rawKey),
+		KeyPrefix: rawKey[:8],
+		Scope:     scope,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Println(err)
+		return "", err
+	}
+	return rawKey, nil
+}
+
+func (k *ApiKeys) GetApiKeyByKey(rawKey string, collectionName string) (ApiKeys, error) {
+	collection := returnCollectionPointer(collectionName)
+	var apiKey ApiKeys
+	filter := bson.M{"_keyhash": auth.HashApiKey(rawKey), "_revokedat": bson.M{"$exists": false}}
+	err := collection.FindOne(context.Background(), filter).Decode(&apiKey)
+	if err != nil {
+		return ApiKeys{}, err
+	}
+	return apiKey, nil
+}
+
+func (k *ApiKeys) GetAllApiKeys(collectionName string) ([]ApiKeys, error) {
+	collection := returnCollectionPointer(collectionName)
+	var apiKeys []ApiKeys
+
+	cursor, err := collection.Find(context.Background(), bson.D{})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var apiKey ApiKeys
+		cursor.Decode(&apiKey)
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, nil
+}
+
+func (k *ApiKeys) RevokeApiKey(name string, collectionName string) error {
+	collection := returnCollectionPointer(collectionName)
+
+	filter := bson.M{"_name": name, "_revokedat": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"_revokedat": time.Now().UTC()}}
+	result, err := collection.UpdateMany(context.Background(), filter, update)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return fmt.Errorf("active api key with provided name doesn't exist")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationImport = "import"
+)
+
+// AuditEntries are only ever inserted; nothing in the app updates them, and
+// only a sync which fails to replace the directory removes the ones it wrote.
+type AuditEntries struct {
+	Actor     string      `json:"actor" bson:"_actor"`
+	RequestID string      `json:"requestid,omitempty" bson:"_requestid,omitempty"`
+	Operation string      `json:"operation" bson:"_operation"`
+	SwiftCode string      `json:"swiftcode" bson:"_swiftcode"`
+	Timestamp time.Time   `json:"timestamp" bson:"_timestamp"`
+	Before    *SwiftCodes `json:"before,omitempty" bson:"_before,omitempty"`
+	After     *SwiftCodes `json:"after,omitempty" bson:"_after,omitempty"`
+}
+
+type AuditFilter struct {
+	SwiftCode string
+	Actor     string
+	From      time.Time
+	To        time.Time
+	Limit     int64
+}
+
+// AuditContext describes who is changing the directory. Handlers and the
+// importer put it into the context passed to the mutating methods.
+type AuditContext struct {
+	Actor     string
+	RequestID string
+}
+
+type auditContextKey struct{}
+
+func WithAuditContext(ctx context.Context, auditContext AuditContext) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, auditContext)
+}
+
+func auditContextFrom(ctx context.Context) AuditContext {
+	auditContext, _ := ctx.Value(auditContextKey{}).(AuditContext)
+	if auditContext.Actor == "" {
+		auditContext.Actor = "system"
+	}
+	return auditContext
+}
+
+func auditCollectionName(collectionName string) string {
+	return collectionName + "_audit"
+}
+
+func appendAuditEntry(ctx context.Context, operation string, swiftCodeName string, before *SwiftCodes, after *SwiftCodes, collectionName string) error {
+	collection := returnCollectionPointer(auditCollectionName(collectionName))
+	auditContext := auditContextFrom(ctx)
+
+	_, err := collection.InsertOne(ctx, AuditEntries{
+		Actor:     auditContext.Actor,
+		RequestID: auditContext.RequestID,
+		Operation: operation,
+		SwiftCode: swiftCodeName,
+		Timestamp: time.Now().UTC(),
+		Before:    before,
+		After:     after,
+	})
+	if err != nil {
+		log.Println("Error while writing audit entry", err)
+	}
+	return err
+}
+
+// GetAuditEntries returns the newest entries of the directory stored in
+// collectionName matching the filter. Zero filter fields are ignored.
+func (a *AuditEntries) GetAuditEntries(filter AuditFilter, collectionName string) ([]AuditEntries, error) {
+	collection := returnCollectionPointer(auditCollectionName(collectionName))
+	auditEntries := []AuditEntries{}
+
+	query := bson.M{}
+	if filter.SwiftCode != "" {
+		query["_swiftcode"] = filter.SwiftCode
+	}
+	if filter.Actor != "" {
+		query["_actor"] = filter.Actor
+	}
+	timestamp := bson.M{}
+	if !filter.From.IsZero() {
+		timestamp["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timestamp["$lte"] = filter.To
+	}
+	if len(timestamp) != 0 {
+		query["_timestamp"] = timestamp
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_timestamp", Value: -1}}).SetLimit(limit)
+
+	cursor, err := collection.Find(context.Background(), query, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var auditEntry AuditEntries
+		cursor.Decode(&auditEntry)
+		auditEntries = append(auditEntries, auditEntry)
+	}
+
+	return auditEntries, nil
+}
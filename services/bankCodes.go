@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ResolvedByBankCode means the bank code of the IBAN was found in the
+	// bank code table.
+	ResolvedByBankCode = "bank-code"
+	// ResolvedByBicPrefix means the bank code of the IBAN is the first four
+	// characters of the BIC, as in the UK or the Netherlands.
+	ResolvedByBicPrefix = "bic-prefix"
+)
+
+// BankCodes link a national bank code to a swift code. Scheme is one of the
+// clearing schemes, such as UK sort codes or US routing numbers, or
+// SchemeBankCode for bank codes as found in IBANs, which may include the
+// branch code for countries where IBANs carry one.
+type BankCodes struct {
+	CountryISO2Code string    `json:"countryiso2code" bson:"_countryiso2code"`
+	Scheme          string    `json:"scheme" bson:"_scheme"`
+	BankCode        string    `json:"bankcode" bson:"_bankcode"`
+	SwiftCode       string    `json:"swiftcode" bson:"_swiftcode"`
+	UpdatedAt       time.Time `json:"updatedat" bson:"_updatedat"`
+}
+
+// IbanResolutions are a parsed IBAN with the bank it belongs to, when the
+// bank could be found.
+type IbanResolutions struct {
+	Ibans
+	Bank       *SwiftCodes `json:"bank,omitempty"`
+	ResolvedBy string      `json:"resolvedby,omitempty"`
+}
+
+// BankCodeResolutions are a bank code with the bank it belongs to, when the
+// bank is in effect.
+type BankCodeResolutions struct {
+	BankCodes
+	Bank *SwiftCodes `json:"bank,omitempty"`
+}
+
+// ImportBankCodes stores bank codes, replacing the swift code of codes
+// already known, and returns how many were stored. Codes without a scheme
+// get the scheme of their country.
+func (b *BankCodes) ImportBankCodes(ctx context.Context, bankCodes []BankCodes, collectionName string) (int, error) {
+	collection := returnCollectionPointer(collectionName)
+	updatedAt := now()
+
+	for i, bankCode := range bankCodes {
+		if bankCode.Scheme == "" {
+			bankCode.Scheme = CountryClearingScheme(bankCode.CountryISO2Code)
+		}
+		bankCode.UpdatedAt = updatedAt
+		// Codes imported before schemes existed have none and are replaced.
+		filter := bson.M{
+			"_countryiso2code": bankCode.CountryISO2Code,
+			"_scheme":          bson.M{"$in": bson.A{bankCode.Scheme, nil}},
+			"_bankcode":        bankCode.BankCode,
+		}
+		_, err := collection.ReplaceOne(ctx, filter, bankCode, options.Replace().SetUpsert(true))
+		if err != nil {
+			log.Println(err)
+			return i, err
+		}
+	}
+	return len(bankCodes), nil
+}
+
+func (b *BankCodes) FindBankCode(isoCode string, bankCodeName string, collectionName string) (BankCodes, error) {
+	collection := returnCollectionPointer(collectionName)
+	var bankCode BankCodes
+	err := collection.FindOne(context.Background(), bson.M{"_countryiso2code": isoCode, "_bankcode": bankCodeName}).Decode(&bankCode)
+	if err != nil {
+		return BankCodes{}, err
+	}
+	return bankCode, nil
+}
+
+// FindBankCodeInScheme finds the swift code of a code of a scheme. isoCode
+// may be empty for schemes used in a single country. The scheme of a country
+// also finds the codes of its IBANs, which is how codes without a scheme
+// are stored, such as German Bankleitzahlen.
+func (b *BankCodes) FindBankCodeInScheme(scheme string, isoCode string, code string, collectionName string) (BankCodes, error) {
+	isoCode = schemeCountry(scheme, isoCode)
+	normalized, err := ValidateBankCode(scheme, isoCode, code)
+	if err != nil {
+		return BankCodes{}, err
+	}
+
+	// Codes of the scheme itself come first.
+	schemes := bson.A{scheme}
+	if scheme == CountryClearingScheme(isoCode) && scheme != SchemeBankCode {
+		schemes = append(schemes, nil, SchemeBankCode)
+	}
+	collection := returnCollectionPointer(collectionName)
+	for _, candidate := range schemes {
+		filter := bson.M{"_countryiso2code": isoCode, "_bankcode": normalized, "_scheme": candidate}
+		var bankCode BankCodes
+		err = collection.FindOne(context.Background(), filter).Decode(&bankCode)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return BankCodes{}, err
+		}
+		bankCode.Scheme = scheme
+		return bankCode, nil
+	}
+	return BankCodes{}, mongo.ErrNoDocuments
+}
+
+// GetBankCodesOfSwiftCode lists the national codes of a swift code, sorted by
+// scheme and code.
+func (b *BankCodes) GetBankCodesOfSwiftCode(swiftCode string, collectionName string) ([]BankCodes, error) {
+	collection := returnCollectionPointer(collectionName)
+	cursor, err := collection.Find(context.Background(), bson.M{"_swiftcode": swiftCode})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	bankCodes := []BankCodes{}
+	if err = cursor.All(context.Background(), &bankCodes); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	for i := range bankCodes {
+		if bankCodes[i].Scheme == "" {
+			bankCodes[i].Scheme = CountryClearingScheme(bankCodes[i].CountryISO2Code)
+		}
+	}
+	sort.Slice(bankCodes, func(i, j int) bool {
+		if bankCodes[i].Scheme != bankCodes[j].Scheme {
+			return bankCodes[i].Scheme < bankCodes[j].Scheme
+		}
+		return bankCodes[i].BankCode < bankCodes[j].BankCode
+	})
+	return bankCodes, nil
+}
+
+// ResolveBankCode finds a code of a scheme and the bank it belongs to.
+func (t *SwiftCodes) ResolveBankCode(scheme string, isoCode string, code string, bankCodesCollectionName string, collectionName string) (BankCodeResolutions, error) {
+	var bankCodes BankCodes
+	bankCode, err := bankCodes.FindBankCodeInScheme(scheme, isoCode, code, bankCodesCollectionName)
+	if err != nil {
+		return BankCodeResolutions{}, err
+	}
+
+	resolution := BankCodeResolutions{BankCodes: bankCode}
+	bank, err := t.FindSwiftCode(bankCode.SwiftCode, LookupOptions{}, collectionName)
+	if err == mongo.ErrNoDocuments {
+		return resolution, nil
+	}
+	if err != nil {
+		return resolution, err
+	}
+	resolution.Bank = &bank
+	return resolution, nil
+}
+
+var bicPrefix = regexp.MustCompile(`^[A-Z]{4}$`)
+
+// ResolveIban finds the bank of an IBAN. The bank code table is looked up
+// with the bank and branch code first, then with the bank code alone, then
+// with the branch code alone in countries such as the UK whose national
+// scheme is carried as the branch code. When
+// neither is known and the bank code looks like the start of a BIC, the
+// headquarter with that bank and country code is used.
+func (t *SwiftCodes) ResolveIban(iban Ibans, bankCodesCollectionName string, collectionName string) (IbanResolutions, error) {
+	resolution := IbanResolutions{Ibans: iban}
+
+	var bankCodes BankCodes
+	candidates := []string{iban.BankCode}
+	if iban.BranchCode != "" {
+		candidates = []string{iban.BankCode + iban.BranchCode, iban.BankCode}
+		if clearingSchemes[CountryClearingScheme(iban.CountryISO2Code)].InIbanBranch {
+			candidates = append(candidates, iban.BranchCode)
+		}
+	}
+	for _, candidate := range candidates {
+		bankCode, err := bankCodes.FindBankCode(iban.CountryISO2Code, candidate, bankCodesCollectionName)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			log.Println(err)
+			return resolution, err
+		}
+		bank, err := t.FindSwiftCode(bankCode.SwiftCode, LookupOptions{}, collectionName)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return resolution, err
+		}
+		resolution.Bank = &bank
+		resolution.ResolvedBy = ResolvedByBankCode
+		return resolution, nil
+	}
+
+	if !bicPrefix.MatchString(iban.BankCode) {
+		return resolution, nil
+	}
+	swiftCodes, err := findSwiftCodes(bson.M{"_swiftcode": bson.M{"$regex": "^" + iban.BankCode + iban.CountryISO2Code}}, LookupOptions{}, collectionName)
+	if err != nil {
+		return resolution, err
+	}
+	if len(swiftCodes) == 0 {
+		return resolution, nil
+	}
+	sort.Slice(swiftCodes, func(i, j int) bool {
+		if swiftCodes[i].IsHeadQuater != swiftCodes[j].IsHeadQuater {
+			return swiftCodes[i].IsHeadQuater
+		}
+		return swiftCodes[i].SwiftCode < swiftCodes[j].SwiftCode
+	})
+	resolution.Bank = &swiftCodes[0]
+	resolution.ResolvedBy = ResolvedByBicPrefix
+	return resolution, nil
+}
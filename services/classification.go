@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Classifications of a BIC, derived from the second character of its
+// location code as ISO 9362 defines it.
+const (
+	ClassificationLive = "live"
+	// ClassificationTest marks test and training BICs, location code "?0".
+	ClassificationTest = "test"
+	// ClassificationPassive marks passive participants, BICs not connected
+	// to the network, location code "?1".
+	ClassificationPassive = "passive"
+	// ClassificationReverseBilling marks BICs whose messages are paid for by
+	// the receiver, location code "?2".
+	ClassificationReverseBilling = "reverse-billing"
+)
+
+// ClassifySwiftCode derives the classification of a swift code.
+func ClassifySwiftCode(swiftCode string) string {
+	if len(swiftCode) < 8 {
+		return ClassificationLive
+	}
+	switch swiftCode[7] {
+	case '0':
+		return ClassificationTest
+	case '1':
+		return ClassificationPassive
+	case '2':
+		return ClassificationReverseBilling
+	}
+	return ClassificationLive
+}
+
+// ParseClassifications reads a comma separated list of classifications.
+func ParseClassifications(value string) ([]string, error) {
+	var classifications []string
+	for _, classification := range strings.Split(value, ",") {
+		classification = strings.ToLower(strings.TrimSpace(classification))
+		switch classification {
+		case "":
+			continue
+		case ClassificationLive, ClassificationTest, ClassificationPassive, ClassificationReverseBilling:
+			classifications = append(classifications, classification)
+		default:
+			return nil, fmt.Errorf("unknown classification %q, use live, test, passive or reverse-billing", classification)
+		}
+	}
+	return classifications, nil
+}
+
+// ClassificationWarning explains why a code shouldn't be used for live
+// payments, it is empty for codes which can be.
+func ClassificationWarning(classification string) string {
+	switch classification {
+	case ClassificationTest:
+		return "test and training BIC, not used for live payments"
+	case ClassificationPassive:
+		return "passive participant, the BIC isn't connected to the network"
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemeBankCode is the scheme of bank codes as found in IBANs of countries
+// without a scheme of their own.
+const SchemeBankCode = "bank-code"
+
+// ClearingSchemes are national bank code systems. A code of a scheme is
+// normalized by dropping spaces and dashes and upper casing, then has to match
+// pattern and pass checkDigit when the scheme has one.
+type ClearingSchemes struct {
+	Name            string `json:"name"`
+	CountryISO2Code string `json:"countryiso2code"`
+	Description     string `json:"description"`
+	HasCheckDigit   bool   `json:"hascheckdigit"`
+	// InIbanBranch means IBANs carry the code as their branch code, not
+	// their bank code.
+	InIbanBranch bool `json:"-"`
+
+	pattern    *regexp.Regexp
+	checkDigit func(code string) bool
+}
+
+var clearingSchemes = map[string]ClearingSchemes{
+	"sort-code": {
+		Name:            "sort-code",
+		CountryISO2Code: "GB",
+		Description:     "UK sort code, 6 digits",
+		InIbanBranch:    true,
+		pattern:         regexp.MustCompile(`^[0-9]{6}$`),
+	},
+	"aba": {
+		Name:            "aba",
+		CountryISO2Code: "US",
+		Description:     "US ABA routing number, 9 digits with a check digit",
+		HasCheckDigit:   true,
+		pattern:         regexp.MustCompile(`^(0[0-9]|1[0-2]|2[1-9]|3[0-2]|6[1-9]|7[0-2]|80)[0-9]{7}$`),
+		checkDigit:      abaCheckDigit,
+	},
+	"blz": {
+		Name:            "blz",
+		CountryISO2Code: "DE",
+		Description:     "German Bankleitzahl, 8 digits not starting with 0 or 9",
+		pattern:         regexp.MustCompile(`^[1-8][0-9]{7}$`),
+	},
+	"pl-bank-number": {
+		Name:            "pl-bank-number",
+		CountryISO2Code: "PL",
+		Description:     "Polish bank settlement number, 8 digits with a check digit",
+		HasCheckDigit:   true,
+		pattern:         regexp.MustCompile(`^[0-9]{8}$`),
+		checkDigit:      polishBankNumberCheckDigit,
+	},
+	"bsb": {
+		Name:            "bsb",
+		CountryISO2Code: "AU",
+		Description:     "Australian BSB number, 6 digits",
+		pattern:         regexp.MustCompile(`^[0-9]{6}$`),
+	},
+	"ifsc": {
+		Name:            "ifsc",
+		CountryISO2Code: "IN",
+		Description:     "Indian Financial System Code, 4 letters, 0 and 6 letters or digits",
+		pattern:         regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`),
+	},
+}
+
+// abaCheckDigit checks the weighted 3-7-1 sum of a routing number.
+func abaCheckDigit(code string) bool {
+	weights := []int{3, 7, 1}
+	sum := 0
+	for i, r := range code {
+		sum += int(r-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
+
+// polishBankNumberCheckDigit checks the last digit of a settlement number
+// against the 3-9-7-1 weighted sum of the first seven.
+func polishBankNumberCheckDigit(code string) bool {
+	weights := []int{3, 9, 7, 1, 3, 9, 7}
+	sum := 0
+	for i, weight := range weights {
+		sum += int(code[i]-'0') * weight
+	}
+	return int(code[7]-'0') == (10-sum%10)%10
+}
+
+// ClearingSchemeNames lists the schemes, sorted.
+func ClearingSchemeNames() []string {
+	names := []string{SchemeBankCode}
+	for name := range clearingSchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CountryClearingScheme is the scheme of a country, SchemeBankCode for
+// countries without one.
+func CountryClearingScheme(isoCode string) string {
+	for _, scheme := range clearingSchemes {
+		if scheme.CountryISO2Code == isoCode {
+			return scheme.Name
+		}
+	}
+	return SchemeBankCode
+}
+
+func normalizeBankCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// schemeCountry is isoCode, or the country of the scheme when isoCode is
+// empty.
+func schemeCountry(schemeName string, isoCode string) string {
+	if isoCode == "" {
+		return clearingSchemes[schemeName].CountryISO2Code
+	}
+	return isoCode
+}
+
+// ValidateBankCode checks a code of a scheme used in a country and returns it
+// normalized. isoCode may be empty for schemes of a single country.
+func ValidateBankCode(schemeName string, isoCode string, code string) (string, error) {
+	normalized := normalizeBankCode(code)
+	isoCode = schemeCountry(schemeName, isoCode)
+	if schemeName == SchemeBankCode {
+		if isoCode == "" {
+			return "", fmt.Errorf("bank codes need a country")
+		}
+		if normalized == "" || !isAlphanumeric(normalized) {
+			return "", fmt.Errorf("bank code must be letters and digits")
+		}
+		return normalized, nil
+	}
+
+	scheme, ok := clearingSchemes[schemeName]
+	if !ok {
+		return "", fmt.Errorf("unknown bank code scheme %q, use one of %s", schemeName, strings.Join(ClearingSchemeNames(), ", "))
+	}
+	if isoCode != scheme.CountryISO2Code {
+		return "", fmt.Errorf("%s codes belong to %s, not %s", scheme.Name, scheme.CountryISO2Code, isoCode)
+	}
+	if !scheme.pattern.MatchString(normalized) {
+		return "", fmt.Errorf("%s is not a valid %s: %s", code, scheme.Name, scheme.Description)
+	}
+	if scheme.checkDigit != nil && !scheme.checkDigit(normalized) {
+		return "", fmt.Errorf("%s has a wrong check digit for a %s", code, scheme.Name)
+	}
+	return normalized, nil
+}
+
+func isAlphanumeric(value string) bool {
+	for _, r := range value {
+		if !(r >= '0' && r <= '9') && !(r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
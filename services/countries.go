@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// iso3166 is the ISO 3166-1 table: alpha-2, alpha-3 and numeric codes, the
+// name used in the directory and other accepted names separated with "|".
+//
+//go:embed iso3166.csv
+var iso3166 []byte
+
+// Countries are entries of the ISO 3166-1 table. SwiftCodeCount is only
+// filled by GetCountries.
+type Countries struct {
+	Alpha2         string   `json:"alpha2"`
+	Alpha3         string   `json:"alpha3"`
+	Numeric        string   `json:"numeric"`
+	Name           string   `json:"name"`
+	Aliases        []string `json:"aliases,omitempty"`
+	SwiftCodeCount int      `json:"swiftcodecount"`
+}
+
+var countries []Countries
+var countriesByCode = map[string]Countries{}
+
+func init() {
+	records, err := csv.NewReader(bytes.NewReader(iso3166)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("iso3166.csv: %v", err))
+	}
+	for _, record := range records[1:] {
+		country := Countries{Alpha2: record[0], Alpha3: record[1], Numeric: record[2], Name: record[3]}
+		if record[4] != "" {
+			country.Aliases = strings.Split(record[4], "|")
+		}
+		countries = append(countries, country)
+		countriesByCode[country.Alpha2] = country
+	}
+}
+
+// LookupCountry finds a country by its alpha-2 code.
+func LookupCountry(isoCode string) (Countries, bool) {
+	country, ok := countriesByCode[strings.ToUpper(isoCode)]
+	return country, ok
+}
+
+// foldName makes names differing in case, accents and spacing equal.
+func foldName(name string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), name)
+	if err != nil {
+		folded = name
+	}
+	return strings.ToUpper(strings.Join(strings.Fields(folded), " "))
+}
+
+// Matches tells whether name is the name of the country or one of its
+// aliases.
+func (c Countries) Matches(name string) bool {
+	folded := foldName(name)
+	if folded == foldName(c.Name) {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if folded == foldName(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateCountry checks an ISO2 code against the ISO 3166-1 table and
+// returns the canonical name of the country. An empty name is filled in,
+// a name belonging to another country is an error.
+func ValidateCountry(isoCode string, name string) (string, error) {
+	if len(isoCode) != 2 {
+		return "", fmt.Errorf("iso2 code must be exactly 2 characters")
+	}
+	country, ok := LookupCountry(isoCode)
+	if !ok {
+		return "", fmt.Errorf("%s is not an ISO 3166-1 country code", isoCode)
+	}
+	if strings.TrimSpace(name) != "" && !country.Matches(name) {
+		return "", fmt.Errorf("country name %s doesn't match %s, expected %s", name, country.Alpha2, country.Name)
+	}
+	return country.Name, nil
+}
+
+// GetCountries lists every ISO 3166-1 country with the number of its codes in
+// effect now.
+func (t *SwiftCodes) GetCountries(collectionName string) ([]Countries, error) {
+	swiftCodes, err := findSwiftCodes(bson.M{}, LookupOptions{}, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, swiftCode := range swiftCodes {
+		counts[swiftCode.CountryISO2Code]++
+	}
+	result := make([]Countries, 0, len(countries))
+	for _, country := range countries {
+		country.SwiftCodeCount = counts[country.Alpha2]
+		result = append(result, country)
+	}
+	return result, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	CheckOrphanBranches     = "orphan-branches"
+	CheckCountryCodes       = "unknown-country-codes"
+	CheckCountryNames       = "country-names"
+	CheckMissingTimeZones   = "missing-time-zones"
+	CheckInvalidTimeZones   = "invalid-time-zones"
+	CheckMissingTownNames   = "missing-town-names"
+	CheckDuplicateAddresses = "duplicate-addresses"
+	CheckWhitespace         = "suspicious-whitespace"
+)
+
+// DataQualityReports summarize problems found in the stored directory.
+type DataQualityReports struct {
+	GeneratedAt time.Time           `json:"generatedat"`
+	TotalCodes  int                 `json:"totalcodes"`
+	Checks      []DataQualityChecks `json:"checks"`
+}
+
+// DataQualityChecks count the records failing one check and keep a few of
+// them as samples.
+type DataQualityChecks struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Count       int                 `json:"count"`
+	Samples     []DataQualityIssues `json:"samples"`
+}
+
+type DataQualityIssues struct {
+	SwiftCode string `json:"swiftcode"`
+	Field     string `json:"field,omitempty"`
+	Value     string `json:"value,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+func (c *DataQualityChecks) add(sampleSize int, issue DataQualityIssues) {
+	c.Count++
+	if len(c.Samples) < sampleSize {
+		c.Samples = append(c.Samples, issue)
+	}
+}
+
+var suspiciousWhitespace = regexp.MustCompile(`^\s|\s$|\s\s|[\t\r\n]`)
+
+// normalizeAddress makes addresses differing only in case and spacing equal.
+func normalizeAddress(address string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(address), " "))
+}
+
+// BuildDataQualityReport checks swiftCodes, keeping up to sampleSize sample
+// records per check.
+func BuildDataQualityReport(swiftCodes []SwiftCodes, sampleSize int) DataQualityReports {
+	checks := map[string]*DataQualityChecks{
+		CheckOrphanBranches:     {Name: CheckOrphanBranches, Description: "branches whose headquarter is missing"},
+		CheckCountryCodes:       {Name: CheckCountryCodes, Description: "country codes missing from ISO 3166-1"},
+		CheckCountryNames:       {Name: CheckCountryNames, Description: "country names contradicting the ISO 3166-1 country code"},
+		CheckMissingTimeZones:   {Name: CheckMissingTimeZones, Description: "codes without a time zone"},
+		CheckInvalidTimeZones:   {Name: CheckInvalidTimeZones, Description: "time zones unknown to the IANA time zone database"},
+		CheckMissingTownNames:   {Name: CheckMissingTownNames, Description: "codes without a town name"},
+		CheckDuplicateAddresses: {Name: CheckDuplicateAddresses, Description: "codes of different institutions sharing an address"},
+		CheckWhitespace:         {Name: CheckWhitespace, Description: "names and addresses with leading, trailing, doubled or special whitespace"},
+	}
+
+	headquarters := map[string]bool{}
+	addresses := map[string][]SwiftCodes{}
+	for _, swiftCode := range swiftCodes {
+		if swiftCode.IsHeadQuater && len(swiftCode.SwiftCode) >= 8 {
+			headquarters[swiftCode.SwiftCode[:8]] = true
+		}
+		if address := normalizeAddress(swiftCode.Address); address != "" {
+			addresses[address] = append(addresses[address], swiftCode)
+		}
+	}
+
+	for _, swiftCode := range swiftCodes {
+		issue := DataQualityIssues{SwiftCode: swiftCode.SwiftCode}
+
+		if !swiftCode.IsHeadQuater && len(swiftCode.SwiftCode) >= 8 && !headquarters[swiftCode.SwiftCode[:8]] {
+			checks[CheckOrphanBranches].add(sampleSize, DataQualityIssues{
+				SwiftCode: swiftCode.SwiftCode,
+				Detail:    "no " + swiftCode.SwiftCode[:8] + "XXX",
+			})
+		}
+		if country, ok := LookupCountry(swiftCode.CountryISO2Code); !ok {
+			checks[CheckCountryCodes].add(sampleSize, DataQualityIssues{
+				SwiftCode: swiftCode.SwiftCode,
+				Field:     "countryiso2code",
+				Value:     swiftCode.CountryISO2Code,
+			})
+		} else if !country.Matches(swiftCode.CountryName) {
+			checks[CheckCountryNames].add(sampleSize, DataQualityIssues{
+				SwiftCode: swiftCode.SwiftCode,
+				Field:     "countryname",
+				Value:     swiftCode.CountryName,
+				Detail:    "expected " + country.Name + " for " + country.Alpha2,
+			})
+		}
+		if swiftCode.TimeZone == "" {
+			checks[CheckMissingTimeZones].add(sampleSize, issue)
+		} else if _, err := loadTimeZone(swiftCode.TimeZone); err != nil {
+			checks[CheckInvalidTimeZones].add(sampleSize, DataQualityIssues{
+				SwiftCode: swiftCode.SwiftCode,
+				Field:     "timezone",
+				Value:     swiftCode.TimeZone,
+			})
+		}
+		if strings.TrimSpace(swiftCode.TownName) == "" {
+			checks[CheckMissingTownNames].add(sampleSize, issue)
+		}
+		for _, field := range []struct {
+			name  string
+			value string
+		}{
+			{"bankname", swiftCode.BankName},
+			{"address", swiftCode.Address},
+			{"townname", swiftCode.TownName},
+			{"countryname", swiftCode.CountryName},
+		} {
+			if suspiciousWhitespace.MatchString(field.value) {
+				checks[CheckWhitespace].add(sampleSize, DataQualityIssues{
+					SwiftCode: swiftCode.SwiftCode,
+					Field:     field.name,
+					Value:     field.value,
+				})
+			}
+		}
+	}
+
+	// Branches usually share the address of their headquarter, so only
+	// codes of different institutions count as duplicates.
+	duplicates := []string{}
+	for address, sharing := range addresses {
+		institutions := map[string]bool{}
+		for _, swiftCode := range sharing {
+			if len(swiftCode.SwiftCode) >= 8 {
+				institutions[swiftCode.SwiftCode[:8]] = true
+			}
+		}
+		if len(institutions) > 1 {
+			duplicates = append(duplicates, address)
+		}
+	}
+	sort.Strings(duplicates)
+	for _, address := range duplicates {
+		var codes []string
+		for _, swiftCode := range addresses[address] {
+			codes = append(codes, swiftCode.SwiftCode)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			checks[CheckDuplicateAddresses].add(sampleSize, DataQualityIssues{
+				SwiftCode: code,
+				Field:     "address",
+				Value:     address,
+				Detail:    "shared by " + strings.Join(codes, ", "),
+			})
+		}
+	}
+
+	report := DataQualityReports{
+		GeneratedAt: now(),
+		TotalCodes:  len(swiftCodes),
+	}
+	for _, name := range []string{CheckOrphanBranches, CheckCountryCodes, CheckCountryNames, CheckMissingTimeZones, CheckInvalidTimeZones, CheckMissingTownNames, CheckDuplicateAddresses, CheckWhitespace} {
+		check := checks[name]
+		if check.Samples == nil {
+			check.Samples = []DataQualityIssues{}
+		}
+		report.Checks = append(report.Checks, *check)
+	}
+	return report
+}
+
+// GetDataQualityReport checks the codes in effect now.
+func (t *SwiftCodes) GetDataQualityReport(sampleSize int, collectionName string) (DataQualityReports, error) {
+	swiftCodes, err := findSwiftCodes(bson.M{}, LookupOptions{}, collectionName)
+	if err != nil {
+		return DataQualityReports{}, err
+	}
+	return BuildDataQualityReport(swiftCodes, sampleSize), nil
+}
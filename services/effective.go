@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	ChangeAddition = "addition"
+	ChangeRemoval  = "removal"
+)
+
+type UpcomingChanges struct {
+	SwiftCode       string    `json:"swiftcode"`
+	BankName        string    `json:"bankname"`
+	CountryISO2Code string    `json:"countryiso2code"`
+	Change          string    `json:"change"`
+	EffectiveAt     time.Time `json:"effectiveat"`
+}
+
+// ParseDate accepts RFC 3339 timestamps and plain dates, which mean the
+// start of that day in UTC.
+func ParseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func validateValidity(validFrom *time.Time, validTo *time.Time) error {
+	if validFrom != nil && validTo != nil && !validTo.After(*validFrom) {
+		return fmt.Errorf("valid to must be later than valid from")
+	}
+	return nil
+}
+
+// validityUpdate sets the given bounds and removes the missing ones.
+func validityUpdate(validFrom *time.Time, validTo *time.Time) bson.M {
+	set := bson.M{}
+	unset := bson.M{}
+	if validFrom != nil {
+		set["_validfrom"] = validFrom.UTC()
+	} else {
+		unset["_validfrom"] = ""
+	}
+	if validTo != nil {
+		set["_validto"] = validTo.UTC()
+	} else {
+		unset["_validto"] = ""
+	}
+	return bson.M{"$set": set, "$unset": unset}
+}
+
+// SameTime tells whether two optional times are both missing or equal.
+func SameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// effectiveFilter restricts a filter to codes in effect at t.
+func effectiveFilter(filter bson.M, t time.Time) bson.M {
+	filter["_validfrom"] = bson.M{"$not": bson.M{"$gt": t}}
+	filter["_validto"] = bson.M{"$not": bson.M{"$lte": t}}
+	return filter
+}
+
+// SetSwiftCodeValidity reschedules when a code comes into or goes out of
+// effect, e.g. when a directory file announces its removal.
+func (t *SwiftCodes) SetSwiftCodeValidity(ctx context.Context, swiftCodeName string, validFrom *time.Time, validTo *time.Time, operation string, collectionName string) error {
+	if err := validateValidity(validFrom, validTo); err != nil {
+		return err
+	}
+	_, _, err := changeSwiftCode(ctx, notDeleted(bson.M{"_swiftcode": swiftCodeName}), validityUpdate(validFrom, validTo), operation, collectionName)
+	return err
+}
+
+// GetUpcomingChanges lists codes coming into or going out of effect within
+// (from, to], ordered by date.
+func (t *SwiftCodes) GetUpcomingChanges(from time.Time, to time.Time, collectionName string) ([]UpcomingChanges, error) {
+	collection := returnCollectionPointer(collectionName)
+	changes := []UpcomingChanges{}
+
+	window := bson.M{"$gt": from, "$lte": to}
+	filter := notDeleted(bson.M{"$or": bson.A{
+		bson.M{"_validfrom": window},
+		bson.M{"_validto": window},
+	}})
+	cursor, err := collection.Find(context.Background(), filter)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	var swiftCodes []SwiftCodes
+	if err = cursor.All(context.Background(), &swiftCodes); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	inWindow := func(t *time.Time) bool {
+		return t != nil && t.After(from) && !t.After(to)
+	}
+	for _, swiftCode := range swiftCodes {
+		change := UpcomingChanges{
+			SwiftCode:       swiftCode.SwiftCode,
+			BankName:        swiftCode.BankName,
+			CountryISO2Code: swiftCode.CountryISO2Code,
+		}
+		if inWindow(swiftCode.ValidFrom) {
+			change.Change = ChangeAddition
+			change.EffectiveAt = *swiftCode.ValidFrom
+			changes = append(changes, change)
+		}
+		if inWindow(swiftCode.ValidTo) {
+			change.Change = ChangeRemoval
+			change.EffectiveAt = *swiftCode.ValidTo
+			changes = append(changes, change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].EffectiveAt.Before(changes[j].EffectiveAt)
+	})
+	return changes, nil
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Places are gazetteer entries giving the coordinates of a town. They are
+// matched to swift codes by country and the search key of the town name.
+type Places struct {
+	CountryISO2Code string    `json:"countryiso2code" bson:"_countryiso2code"`
+	TownName        string    `json:"townname" bson:"_townname"`
+	Key             string    `json:"-" bson:"_key"`
+	Latitude        float64   `json:"latitude" bson:"_latitude"`
+	Longitude       float64   `json:"longitude" bson:"_longitude"`
+	UpdatedAt       time.Time `json:"updatedat" bson:"_updatedat"`
+}
+
+// GeoPoints are GeoJSON points, longitude first, as the 2dsphere index
+// expects them.
+type GeoPoints struct {
+	Type        string     `json:"type" bson:"type"`
+	Coordinates [2]float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// SwiftCodesNear are codes found around a point with their distance to it.
+type SwiftCodesNear struct {
+	SwiftCodeArrayElemWithCountry
+	TownName   string     `json:"townname"`
+	Location   *GeoPoints `json:"location"`
+	DistanceKm float64    `json:"distancekm"`
+}
+
+const earthRadiusKm = 6371.0088
+
+func gazetteerCollectionName(collectionName string) string {
+	return collectionName + "_gazetteer"
+}
+
+func placeKey(isoCode string, townName string) string {
+	return isoCode + "|" + SearchKey(townName)
+}
+
+func (p Places) location() *GeoPoints {
+	return &GeoPoints{Type: "Point", Coordinates: [2]float64{p.Longitude, p.Latitude}}
+}
+
+// ValidateCoordinates checks a latitude and longitude in degrees.
+func ValidateCoordinates(latitude float64, longitude float64) error {
+	if math.IsNaN(latitude) || latitude < -90 || latitude > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90")
+	}
+	if math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// UpdatePlaceKeys recomputes the keys of the stored places, which change
+// when SearchKey does. Places whose towns now share a key are merged into
+// the one updated last. It returns the number of places rekeyed or merged.
+func (t *SwiftCodes) UpdatePlaceKeys(ctx context.Context, collectionName string) (int, error) {
+	end, err := beginWrite(ctx, collectionName)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	return updatePlaceKeys(ctx, collectionName)
+}
+
+func updatePlaceKeys(ctx context.Context, collectionName string) (int, error) {
+	gazetteer := returnCollectionPointer(gazetteerCollectionName(collectionName))
+	var places []Places
+	cursor, err := gazetteer.Find(ctx, bson.M{})
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	if err = cursor.All(ctx, &places); err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	changed := 0
+	latest := map[string]Places{}
+	for _, place := range places {
+		key := placeKey(place.CountryISO2Code, place.TownName)
+		if key != place.Key {
+			if _, err = gazetteer.DeleteOne(ctx, bson.M{"_key": place.Key}); err != nil {
+				log.Println(err)
+				return changed, err
+			}
+			changed++
+		}
+		place.Key = key
+		if current, ok := latest[key]; !ok || place.UpdatedAt.After(current.UpdatedAt) {
+			latest[key] = place
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	for key, place := range latest {
+		if _, err = gazetteer.ReplaceOne(ctx, bson.M{"_key": key}, place, options.Replace().SetUpsert(true)); err != nil {
+			log.Println(err)
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// ImportPlaces stores the gazetteer of the swift codes in collectionName,
+// replacing the coordinates of towns already known, and locates the stored
+// codes again. It returns how many places were stored.
+func (t *SwiftCodes) ImportPlaces(ctx context.Context, places []Places, collectionName string) (int, error) {
+	gazetteer := returnCollectionPointer(gazetteerCollectionName(collectionName))
+	updatedAt := now()
+	end, err := beginWrite(ctx, collectionName)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	if _, err = updatePlaceKeys(ctx, collectionName); err != nil {
+		return 0, err
+	}
+
+	for i, place := range places {
+		if err := ValidateCoordinates(place.Latitude, place.Longitude); err != nil {
+			return i, fmt.Errorf("%s, %s: %w", place.TownName, place.CountryISO2Code, err)
+		}
+		place.Key = placeKey(place.CountryISO2Code, place.TownName)
+		place.UpdatedAt = updatedAt
+		_, err := gazetteer.ReplaceOne(ctx, bson.M{"_key": place.Key}, place, options.Replace().SetUpsert(true))
+		if err != nil {
+			log.Println(err)
+			return i, err
+		}
+	}
+	if _, err = locateSwiftCodes(ctx, collectionName); err != nil {
+		return len(places), err
+	}
+	return len(places), nil
+}
+
+// loadGazetteer returns the locations of all places by their key.
+func loadGazetteer(ctx context.Context, collectionName string) (map[string]*GeoPoints, error) {
+	gazetteer := returnCollectionPointer(gazetteerCollectionName(collectionName))
+	var places []Places
+	cursor, err := gazetteer.Find(ctx, bson.M{})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if err = cursor.All(ctx, &places); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	locations := map[string]*GeoPoints{}
+	for _, place := range places {
+		locations[place.Key] = place.location()
+	}
+	return locations, nil
+}
+
+// findLocation looks a town up in the gazetteer, it returns nil for towns
+// missing from it.
+func findLocation(ctx context.Context, isoCode string, townName string, collectionName string) (*GeoPoints, error) {
+	if townName == "" {
+		return nil, nil
+	}
+	gazetteer := returnCollectionPointer(gazetteerCollectionName(collectionName))
+	var place Places
+	err := gazetteer.FindOne(ctx, bson.M{"_key": placeKey(isoCode, townName)}).Decode(&place)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return place.location(), nil
+}
+
+// locationUpdate sets the location, or removes it when the town is unknown.
+func locationUpdate(update bson.M, location *GeoPoints) {
+	if location != nil {
+		update["$set"].(bson.M)["_location"] = location
+	} else {
+		update["$unset"].(bson.M)["_location"] = ""
+	}
+}
+
+func sameLocation(a *GeoPoints, b *GeoPoints) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// LocateSwiftCodes gives every stored code the coordinates of its town from
+// the gazetteer. Locations are derived data, so the codes keep their version
+// and no audit entry is written. It returns the number of codes changed.
+func (t *SwiftCodes) LocateSwiftCodes(ctx context.Context, collectionName string) (int, error) {
+	end, err := beginWrite(ctx, collectionName)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+	return locateSwiftCodes(ctx, collectionName)
+}
+
+func locateSwiftCodes(ctx context.Context, collectionName string) (int, error) {
+	locations, err := loadGazetteer(ctx, collectionName)
+	if err != nil {
+		return 0, err
+	}
+
+	collection := returnCollectionPointer(collectionName)
+	var swiftCodes []SwiftCodes
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	if err = cursor.All(ctx, &swiftCodes); err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	changed := 0
+	for _, swiftCode := range swiftCodes {
+		location := locations[placeKey(swiftCode.CountryISO2Code, swiftCode.TownName)]
+		if sameLocation(location, swiftCode.Location) {
+			continue
+		}
+		update := bson.M{"$unset": bson.M{"_location": ""}}
+		if location != nil {
+			update = bson.M{"$set": bson.M{"_location": location}}
+		}
+		if _, err = collection.UpdateOne(ctx, bson.M{"_swiftcode": swiftCode.SwiftCode}, update); err != nil {
+			log.Println(err)
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// distanceKm is the great circle distance between two points.
+func distanceKm(a *GeoPoints, b *GeoPoints) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	lat1, lat2 := toRadians(a.Coordinates[1]), toRadians(b.Coordinates[1])
+	dLat := lat2 - lat1
+	dLon := toRadians(b.Coordinates[0] - a.Coordinates[0])
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// FindSwiftCodesNear lists the located codes within radiusKm of a point,
+// nearest first. It needs the location index, see EnsureIndexes, and can't
+// read past states of the directory.
+func (t *SwiftCodes) FindSwiftCodesNear(ctx context.Context, latitude float64, longitude float64, radiusKm float64, opts LookupOptions, collectionName string) ([]SwiftCodesNear, error) {
+	if err := ValidateCoordinates(latitude, longitude); err != nil {
+		return nil, err
+	}
+	if radiusKm <= 0 {
+		return nil, fmt.Errorf("radius must be greater than 0")
+	}
+	if !opts.AsOf.IsZero() {
+		return nil, fmt.Errorf("near searches can't read the directory as of a past moment")
+	}
+
+	center := &GeoPoints{Type: "Point", Coordinates: [2]float64{longitude, latitude}}
+	swiftCodes, err := findSwiftCodes(bson.M{"_location": bson.M{"$nearSphere": bson.M{
+		"$geometry":    center,
+		"$maxDistance": radiusKm * 1000,
+	}}}, opts, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []SwiftCodesNear{}
+	for _, swiftCode := range swiftCodes {
+		result = append(result, SwiftCodesNear{
+			SwiftCodeArrayElemWithCountry: swiftCode.arrayElemWithCountry(),
+			TownName:                      swiftCode.TownName,
+			Location:                      swiftCode.Location,
+			DistanceKm:                    math.Round(distanceKm(center, swiftCode.Location)*1000) / 1000,
+		})
+	}
+	return result, nil
+}
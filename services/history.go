@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SwiftCodeVersions is a past state of a swift code. A version was the
+// current one from RecordedAt until SupersededAt, when it was replaced by
+// the next version or deleted. The current document is reported as a version
+// without SupersededAt.
+type SwiftCodeVersions struct {
+	SwiftCode    string     `json:"swiftcode" bson:"_swiftcode"`
+	Version      int        `json:"version" bson:"_version"`
+	RecordedAt   time.Time  `json:"recordedat" bson:"_recordedat"`
+	SupersededAt *time.Time `json:"supersededat,omitempty" bson:"_supersededat,omitempty"`
+	EndedBy      string     `json:"endedby,omitempty" bson:"_endedby,omitempty"`
+	Document     SwiftCodes `json:"document" bson:"_document"`
+}
+
+func historyCollectionName(collectionName string) string {
+	return collectionName + "_history"
+}
+
+// now is truncated to what mongo stores, so timestamps read back compare
+// equal to the ones written.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func archiveVersion(ctx context.Context, before SwiftCodes, supersededAt time.Time, endedBy string, collectionName string) error {
+	collection := returnCollectionPointer(historyCollectionName(collectionName))
+
+	_, err := collection.InsertOne(ctx, SwiftCodeVersions{
+		SwiftCode:    before.SwiftCode,
+		Version:      before.Version,
+		RecordedAt:   before.UpdatedAt,
+		SupersededAt: &supersededAt,
+		EndedBy:      endedBy,
+		Document:     before,
+	})
+	if err != nil {
+		log.Println("Error while archiving swift code version", err)
+	}
+	return err
+}
+
+// nextVersion continues the numbering of a code which existed before and was
+// deleted, so versions stay unique in the history.
+func nextVersion(ctx context.Context, swiftCodeName string, collectionName string) (int, error) {
+	collection := returnCollectionPointer(historyCollectionName(collectionName))
+
+	var latest SwiftCodeVersions
+	opts := options.FindOne().SetSort(bson.D{{Key: "_version", Value: -1}})
+	err := collection.FindOne(ctx, bson.M{"_swiftcode": swiftCodeName}, opts).Decode(&latest)
+	if err == mongo.ErrNoDocuments {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return latest.Version + 1, nil
+}
+
+func (t *SwiftCodes) GetSwiftCodeHistory(swiftCodeName string, collectionName string) ([]SwiftCodeVersions, error) {
+	collection := returnCollectionPointer(historyCollectionName(collectionName))
+	versions := []SwiftCodeVersions{}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_version", Value: 1}})
+	cursor, err := collection.Find(context.Background(), bson.M{"_swiftcode": swiftCodeName}, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if err = cursor.All(context.Background(), &versions); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	var current SwiftCodes
+	err = returnCollectionPointer(collectionName).FindOne(context.Background(), bson.M{"_swiftcode": swiftCodeName}).Decode(&current)
+	if err == nil {
+		versions = append(versions, SwiftCodeVersions{
+			SwiftCode:  current.SwiftCode,
+			Version:    current.Version,
+			RecordedAt: current.UpdatedAt,
+			Document:   current,
+		})
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("swift code with provided name doesn't exist")
+	}
+	return versions, nil
+}
+
+// findSwiftCodesAsOf answers filter as it would have been answered at asOf:
+// current documents last changed before asOf plus archived versions which
+// were current at that moment. Documents written before versioning have no
+// timestamps and count as always present.
+func findSwiftCodesAsOf(filter bson.M, asOf time.Time, collectionName string) ([]SwiftCodes, error) {
+	collection := returnCollectionPointer(collectionName)
+	history := returnCollectionPointer(historyCollectionName(collectionName))
+	var swiftCodes []SwiftCodes
+
+	currentFilter := bson.M{"_updatedat": bson.M{"$not": bson.M{"$gt": asOf}}}
+	historyFilter := bson.M{
+		"_recordedat":   bson.M{"$lte": asOf},
+		"_supersededat": bson.M{"$gt": asOf},
+	}
+	for key, value := range filter {
+		currentFilter[key] = value
+		historyFilter["_document."+key] = value
+	}
+
+	cursor, err := collection.Find(context.Background(), currentFilter)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if err = cursor.All(context.Background(), &swiftCodes); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+
+	cursor, err = history.Find(context.Background(), historyFilter)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	var versions []SwiftCodeVersions
+	if err = cursor.All(context.Background(), &versions); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	for _, version := range versions {
+		swiftCodes = append(swiftCodes, version.Document)
+	}
+
+	return swiftCodes, nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ibanFormats describe the BBAN of every country using IBANs, after
+// release 98 of the SWIFT IBAN registry, December 2024: the structure in
+// registry notation, where "8!n" is eight digits, "a" stands for capital
+// letters and "c" for letters or digits, and where the bank and branch codes
+// sit in the BBAN.
+type ibanFormats struct {
+	structure   string
+	bankCode    [2]int
+	branchCode  [2]int
+	totalLength int
+	fields      []ibanFields
+}
+
+type ibanFields struct {
+	length int
+	kind   byte
+}
+
+var ibanRegistry = map[string]*ibanFormats{
+	"AD": {structure: "4!n4!n12!c", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 8}},
+	"AE": {structure: "3!n16!n", bankCode: [2]int{0, 3}},
+	"AL": {structure: "8!n16!c", bankCode: [2]int{0, 3}, branchCode: [2]int{3, 7}},
+	"AT": {structure: "5!n11!n", bankCode: [2]int{0, 5}},
+	"AZ": {structure: "4!a20!c", bankCode: [2]int{0, 4}},
+	"BA": {structure: "3!n3!n8!n2!n", bankCode: [2]int{0, 3}, branchCode: [2]int{3, 6}},
+	"BE": {structure: "3!n7!n2!n", bankCode: [2]int{0, 3}},
+	"BG": {structure: "4!a4!n2!n8!c", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 8}},
+	"BH": {structure: "4!a14!c", bankCode: [2]int{0, 4}},
+	"BI": {structure: "5!n5!n11!n2!n", bankCode: [2]int{0, 5}, branchCode: [2]int{5, 10}},
+	"BR": {structure: "8!n5!n10!n1!a1!c", bankCode: [2]int{0, 8}, branchCode: [2]int{8, 13}},
+	"BY": {structure: "4!c4!n16!c", bankCode: [2]int{0, 4}},
+	"CH": {structure: "5!n12!c", bankCode: [2]int{0, 5}},
+	"CR": {structure: "4!n14!n", bankCode: [2]int{0, 4}},
+	"CY": {structure: "3!n5!n16!c", bankCode: [2]int{0, 3}, branchCode: [2]int{3, 8}},
+	"CZ": {structure: "4!n6!n10!n", bankCode: [2]int{0, 4}},
+	"DE": {structure: "8!n10!n", bankCode: [2]int{0, 8}},
+	"DJ": {structure: "5!n5!n11!n2!n", bankCode: [2]int{0, 5}, branchCode: [2]int{5, 10}},
+	"DK": {structure: "4!n9!n1!n", bankCode: [2]int{0, 4}},
+	"DO": {structure: "4!c20!n", bankCode: [2]int{0, 4}},
+	"EE": {structure: "2!n2!n11!n1!n", bankCode: [2]int{0, 2}},
+	"EG": {structure: "4!n4!n17!n", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 8}},
+	"ES": {structure: "4!n4!n1!n1!n10!n", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 8}},
+	"FI": {structure: "3!n11!n", bankCode: [2]int{0, 3}},
+	"FK": {structure: "2!a12!n", bankCode: [2]int{0, 2}},
+	"FO": {structure: "4!n9!n1!n", bankCode: [2]int{0, 4}},
+	"FR": {structure: "5!n5!n11!c2!n", bankCode: [2]int{0, 5}, branchCode: [2]int{5, 10}},
+	"GB": {structure: "4!a6!n8!n", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 10}},
+	"GE": {structure: "2!a16!n", bankCode: [2]int{0, 2}},
+	"GI": {structure: "4!a15!c", bankCode: [2]int{0, 4}},
+	"GL": {structure: "4!n9!n1!n", bankCode: [2]int{0, 4}},
+	"GR": {structure: "3!n4!n16!c", bankCode: [2]int{0, 3}, branchCode: [2]int{3, 7}},
+	"GT": {structure: "4!c20!c", bankCode: [2]int{0, 4}},
+	"HR": {structure: "7!n10!n", bankCode: [2]int{0, 7}},
+	"HU": {structure: "3!n4!n1!n15!n1!n", bankCode: [2]int{0, 3}, branchCode: [2]int{3, 7}},
+	"IE": {structure: "4!a6!n8!n", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 10}},
+	"IL": {structure: "3!n3!n13!n", bankCode: [2]int{0, 3}, branchCode: [2]int{3, 6}},
+	"IQ": {structure: "4!a3!n12!n", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 7}},
+	"IS": {structure: "4!n2!n6!n10!n", bankCode: [2]int{0, 2}, branchCode: [2]int{2, 4}},
+	"IT": {structure: "1!a5!n5!n12!c", bankCode: [2]int{1, 6}, branchCode: [2]int{6, 11}},
+	"JO": {structure: "4!a4!n18!c", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 8}},
+	"KW": {structure: "4!a22!c", bankCode: [2]int{0, 4}},
+	"KZ": {structure: "3!n13!c", bankCode: [2]int{0, 3}},
+	"LB": {structure: "4!n20!c", bankCode: [2]int{0, 4}},
+	"LC": {structure: "4!a24!c", bankCode: [2]int{0, 4}},
+	"LI": {structure: "5!n12!c", bankCode: [2]int{0, 5}},
+	"LT": {structure: "5!n11!n", bankCode: [2]int{0, 5}},
+	"LU": {structure: "3!n13!c", bankCode: [2]int{0, 3}},
+	"LV": {structure: "4!a13!c", bankCode: [2]int{0, 4}},
+	"LY": {structure: "3!n3!n15!n", bankCode: [2]int{0, 3}, branchCode: [2]int{3, 6}},
+	"MC": {structure: "5!n5!n11!c2!n", bankCode: [2]int{0, 5}, branchCode: [2]int{5, 10}},
+	"MD": {structure: "2!c18!c", bankCode: [2]int{0, 2}},
+	"ME": {structure: "3!n13!n2!n", bankCode: [2]int{0, 3}},
+	"MK": {structure: "3!n10!c2!n", bankCode: [2]int{0, 3}},
+	"MN": {structure: "4!n12!n", bankCode: [2]int{0, 4}},
+	"MR": {structure: "5!n5!n11!n2!n", bankCode: [2]int{0, 5}, branchCode: [2]int{5, 10}},
+	"MT": {structure: "4!a5!n18!c", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 9}},
+	"MU": {structure: "4!a2!n2!n12!n3!n3!a", bankCode: [2]int{0, 6}, branchCode: [2]int{6, 8}},
+	"NI": {structure: "4!a20!n", bankCode: [2]int{0, 4}},
+	"NL": {structure: "4!a10!n", bankCode: [2]int{0, 4}},
+	"NO": {structure: "4!n6!n1!n", bankCode: [2]int{0, 4}},
+	"OM": {structure: "3!n16!c", bankCode: [2]int{0, 3}},
+	"PK": {structure: "4!a16!c", bankCode: [2]int{0, 4}},
+	"PL": {structure: "8!n16!n", bankCode: [2]int{0, 8}},
+	"PS": {structure: "4!a21!c", bankCode: [2]int{0, 4}},
+	"PT": {structure: "4!n4!n11!n2!n", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 8}},
+	"QA": {structure: "4!a21!c", bankCode: [2]int{0, 4}},
+	"RO": {structure: "4!a16!c", bankCode: [2]int{0, 4}},
+	"RS": {structure: "3!n13!n2!n", bankCode: [2]int{0, 3}},
+	"RU": {structure: "9!n5!n15!c", bankCode: [2]int{0, 9}, branchCode: [2]int{9, 14}},
+	"SA": {structure: "2!n18!c", bankCode: [2]int{0, 2}},
+	"SC": {structure: "4!a2!n2!n16!n3!a", bankCode: [2]int{0, 6}, branchCode: [2]int{6, 8}},
+	"SD": {structure: "2!n12!n", bankCode: [2]int{0, 2}},
+	"SE": {structure: "3!n16!n1!n", bankCode: [2]int{0, 3}},
+	"SI": {structure: "5!n8!n2!n", bankCode: [2]int{0, 5}},
+	"SK": {structure: "4!n6!n10!n", bankCode: [2]int{0, 4}},
+	"SM": {structure: "1!a5!n5!n12!c", bankCode: [2]int{1, 6}, branchCode: [2]int{6, 11}},
+	"SO": {structure: "4!n3!n12!n", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 7}},
+	"ST": {structure: "4!n4!n11!n2!n", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 8}},
+	"SV": {structure: "4!a20!n", bankCode: [2]int{0, 4}},
+	"TL": {structure: "3!n14!n2!n", bankCode: [2]int{0, 3}},
+	"TN": {structure: "2!n3!n13!n2!n", bankCode: [2]int{0, 2}, branchCode: [2]int{2, 5}},
+	"TR": {structure: "5!n1!n16!c", bankCode: [2]int{0, 5}},
+	"UA": {structure: "6!n19!c", bankCode: [2]int{0, 6}},
+	"VA": {structure: "3!n15!n", bankCode: [2]int{0, 3}},
+	"VG": {structure: "4!a16!n", bankCode: [2]int{0, 4}},
+	"XK": {structure: "4!n10!n2!n", bankCode: [2]int{0, 2}, branchCode: [2]int{2, 4}},
+	"YE": {structure: "4!a4!n18!c", bankCode: [2]int{0, 4}, branchCode: [2]int{4, 8}},
+}
+
+func init() {
+	for country, format := range ibanRegistry {
+		format.totalLength = 4
+		for _, part := range splitIbanFields(format.structure) {
+			length, err := strconv.Atoi(strings.TrimSuffix(part[:len(part)-1], "!"))
+			if err != nil {
+				panic(fmt.Sprintf("iban format of %s: %s", country, format.structure))
+			}
+			format.fields = append(format.fields, ibanFields{length: length, kind: part[len(part)-1]})
+			format.totalLength += length
+		}
+	}
+}
+
+// splitIbanFields splits "4!a6!c" into "4!a" and "6!c".
+func splitIbanFields(structure string) []string {
+	var fields []string
+	start := 0
+	for i := 0; i < len(structure); i++ {
+		switch structure[i] {
+		case 'n', 'a', 'c':
+			fields = append(fields, structure[start:i+1])
+			start = i + 1
+		}
+	}
+	return fields
+}
+
+// Ibans are parsed IBANs. Electronic is the IBAN without spaces, Formatted
+// groups it by four characters as it is printed.
+type Ibans struct {
+	Electronic      string `json:"iban"`
+	Formatted       string `json:"formatted"`
+	CountryISO2Code string `json:"countryiso2code"`
+	CheckDigits     string `json:"checkdigits"`
+	BBAN            string `json:"bban"`
+	BankCode        string `json:"bankcode"`
+	BranchCode      string `json:"branchcode,omitempty"`
+}
+
+// ParseIban validates an IBAN: its country, length, the structure of the
+// BBAN and the mod-97 check digits. Spaces and case are ignored.
+func ParseIban(value string) (Ibans, error) {
+	electronic := strings.ToUpper(strings.Join(strings.Fields(value), ""))
+	if len(electronic) < 5 {
+		return Ibans{}, fmt.Errorf("iban is too short")
+	}
+
+	countryCode := electronic[:2]
+	format, ok := ibanRegistry[countryCode]
+	if !ok {
+		return Ibans{}, fmt.Errorf("%s doesn't use IBANs", countryCode)
+	}
+	if len(electronic) != format.totalLength {
+		return Ibans{}, fmt.Errorf("%s IBANs have %d characters, not %d", countryCode, format.totalLength, len(electronic))
+	}
+	if !isDigits(electronic[2:4]) {
+		return Ibans{}, fmt.Errorf("iban check digits must be digits")
+	}
+
+	bban := electronic[4:]
+	position := 0
+	for _, field := range format.fields {
+		part := bban[position : position+field.length]
+		for _, r := range part {
+			if !ibanCharacterMatches(r, field.kind) {
+				return Ibans{}, fmt.Errorf("iban doesn't match the %s format %s at character %d", countryCode, format.structure, position+5)
+			}
+			position++
+		}
+	}
+
+	if ibanChecksum(bban+electronic[:4]) != 1 {
+		return Ibans{}, fmt.Errorf("iban check digits are wrong")
+	}
+
+	iban := Ibans{
+		Electronic:      electronic,
+		Formatted:       formatIban(electronic),
+		CountryISO2Code: countryCode,
+		CheckDigits:     electronic[2:4],
+		BBAN:            bban,
+		BankCode:        bban[format.bankCode[0]:format.bankCode[1]],
+	}
+	if format.branchCode[1] > 0 {
+		iban.BranchCode = bban[format.branchCode[0]:format.branchCode[1]]
+	}
+	return iban, nil
+}
+
+func ibanCharacterMatches(r rune, kind byte) bool {
+	isDigit := r >= '0' && r <= '9'
+	isLetter := r >= 'A' && r <= 'Z'
+	switch kind {
+	case 'n':
+		return isDigit
+	case 'a':
+		return isLetter
+	}
+	return isDigit || isLetter
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// ibanChecksum is the ISO 7064 mod 97-10 remainder of value with letters
+// replaced by 10 to 35.
+func ibanChecksum(value string) int64 {
+	var digits strings.Builder
+	for _, r := range value {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	number, _ := new(big.Int).SetString(digits.String(), 10)
+	return new(big.Int).Mod(number, big.NewInt(97)).Int64()
+}
+
+func formatIban(electronic string) string {
+	var groups []string
+	for i := 0; i < len(electronic); i += 4 {
+		end := i + 4
+		if end > len(electronic) {
+			end = len(electronic)
+		}
+		groups = append(groups, electronic[i:end])
+	}
+	return strings.Join(groups, " ")
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportSucceeded = "succeeded"
+	ImportFailed    = "failed"
+	ImportCancelled = "cancelled"
+)
+
+// MaxRejectedRows bounds how many rejected rows a job keeps; further
+// rejections are only counted.
+const MaxRejectedRows = 100
+
+// ImportJobs are directory files imported in the background. The uploaded
+// file is kept in GridFS until the job finishes, so unfinished jobs can be
+// resumed after a restart.
+type ImportJobs struct {
+	ID            string         `json:"id" bson:"_id"`
+	FileName      string         `json:"filename" bson:"_filename"`
+	Format        string         `json:"format,omitempty" bson:"_format,omitempty"`
+	Size          int64          `json:"size" bson:"_size"`
+	Status        string         `json:"status" bson:"_status"`
+	Actor         string         `json:"actor" bson:"_actor"`
+	RequestID     string         `json:"requestid,omitempty" bson:"_requestid,omitempty"`
+	CreatedAt     time.Time      `json:"createdat" bson:"_createdat"`
+	StartedAt     *time.Time     `json:"startedat,omitempty" bson:"_startedat,omitempty"`
+	FinishedAt    *time.Time     `json:"finishedat,omitempty" bson:"_finishedat,omitempty"`
+	TotalRows     int            `json:"totalrows" bson:"_totalrows"`
+	ProcessedRows int            `json:"processedrows" bson:"_processedrows"`
+	ImportedRows  int            `json:"importedrows" bson:"_importedrows"`
+	SkippedRows   int            `json:"skippedrows" bson:"_skippedrows"`
+	RejectedCount int            `json:"rejectedcount" bson:"_rejectedcount"`
+	RejectedRows  []RejectedRows `json:"rejectedrows" bson:"_rejectedrows"`
+	// OrphanCount is the number of imported branches whose headquarter was
+	// missing, see OrphanPolicies.
+	OrphanCount     int    `json:"orphancount" bson:"_orphancount"`
+	Error           string `json:"error,omitempty" bson:"_error,omitempty"`
+	CancelRequested bool   `json:"cancelrequested,omitempty" bson:"_cancelrequested,omitempty"`
+}
+
+type RejectedRows struct {
+	Line      int    `json:"line" bson:"_line"`
+	SwiftCode string `json:"swiftcode,omitempty" bson:"_swiftcode,omitempty"`
+	Reason    string `json:"reason" bson:"_reason"`
+}
+
+// ImportProgress is the part of a job the runner updates while importing.
+type ImportProgress struct {
+	// Format is the detected format when the job didn't name one.
+	Format        string
+	TotalRows     int
+	ProcessedRows int
+	ImportedRows  int
+	SkippedRows   int
+	RejectedCount int
+	RejectedRows  []RejectedRows
+	OrphanCount   int
+}
+
+// Reject records a rejected row, keeping at most MaxRejectedRows of them.
+func (p *ImportProgress) Reject(line int, swiftCode string, reason string) {
+	p.RejectedCount++
+	if len(p.RejectedRows) < MaxRejectedRows {
+		p.RejectedRows = append(p.RejectedRows, RejectedRows{Line: line, SwiftCode: swiftCode, Reason: reason})
+	}
+}
+
+func (j ImportJobs) Progress() ImportProgress {
+	return ImportProgress{
+		Format:        j.Format,
+		TotalRows:     j.TotalRows,
+		ProcessedRows: j.ProcessedRows,
+		ImportedRows:  j.ImportedRows,
+		SkippedRows:   j.SkippedRows,
+		RejectedCount: j.RejectedCount,
+		RejectedRows:  j.RejectedRows,
+		OrphanCount:   j.OrphanCount,
+	}
+}
+
+func (j ImportJobs) IsFinished() bool {
+	return j.Status == ImportSucceeded || j.Status == ImportFailed || j.Status == ImportCancelled
+}
+
+func payloadBucket(collectionName string) (*gridfs.Bucket, error) {
+	db := returnCollectionPointer(collectionName).Database()
+	return gridfs.NewBucket(db, options.GridFSBucket().SetName(collectionName+"_payloads"))
+}
+
+// CreateImportJob stores the uploaded file and queues a job importing it.
+// An empty format is detected from the content. The job is attributed to the
+// actor found in ctx.
+func (j *ImportJobs) CreateImportJob(ctx context.Context, fileName string, format string, payload io.Reader, collectionName string) (ImportJobs, error) {
+	collection := returnCollectionPointer(collectionName)
+	auditContext := auditContextFrom(ctx)
+
+	bucket, err := payloadBucket(collectionName)
+	if err != nil {
+		log.Println(err)
+		return ImportJobs{}, err
+	}
+
+	job := ImportJobs{
+		ID:           primitive.NewObjectID().Hex(),
+		FileName:     fileName,
+		Format:       format,
+		Status:       ImportQueued,
+		Actor:        auditContext.Actor,
+		RequestID:    auditContext.RequestID,
+		CreatedAt:    now(),
+		RejectedRows: []RejectedRows{},
+	}
+
+	upload, err := bucket.OpenUploadStreamWithID(job.ID, job.FileName)
+	if err != nil {
+		log.Println(err)
+		return ImportJobs{}, err
+	}
+	job.Size, err = io.Copy(upload, payload)
+	if err != nil {
+		upload.Abort()
+		log.Println(err)
+		return ImportJobs{}, err
+	}
+	if err = upload.Close(); err != nil {
+		log.Println(err)
+		return ImportJobs{}, err
+	}
+
+	if _, err = collection.InsertOne(ctx, job); err != nil {
+		log.Println(err)
+		return ImportJobs{}, err
+	}
+	return job, nil
+}
+
+func (j *ImportJobs) GetImportJob(id string, collectionName string) (ImportJobs, error) {
+	collection := returnCollectionPointer(collectionName)
+	var job ImportJobs
+	err := collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return ImportJobs{}, fmt.Errorf("import job with provided id doesn't exist")
+	}
+	if err != nil {
+		log.Println(err)
+		return ImportJobs{}, err
+	}
+	return job, nil
+}
+
+// GetAllImportJobs returns the most recent jobs first.
+func (j *ImportJobs) GetAllImportJobs(limit int64, collectionName string) ([]ImportJobs, error) {
+	collection := returnCollectionPointer(collectionName)
+	jobs := []ImportJobs{}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_createdat", Value: -1}}).SetLimit(limit)
+	cursor, err := collection.Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if err = cursor.All(context.Background(), &jobs); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetUnfinishedImportJobs returns queued and interrupted jobs, oldest first.
+func (j *ImportJobs) GetUnfinishedImportJobs(collectionName string) ([]ImportJobs, error) {
+	collection := returnCollectionPointer(collectionName)
+	jobs := []ImportJobs{}
+
+	filter := bson.M{"_status": bson.M{"$in": bson.A{ImportQueued, ImportRunning}}}
+	opts := options.Find().SetSort(bson.D{{Key: "_createdat", Value: 1}})
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if err = cursor.All(context.Background(), &jobs); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ReadImportPayload returns the file uploaded for a job.
+func (j *ImportJobs) ReadImportPayload(id string, collectionName string) ([]byte, error) {
+	bucket, err := payloadBucket(collectionName)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	var payload bytes.Buffer
+	if _, err = bucket.DownloadToStream(id, &payload); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return payload.Bytes(), nil
+}
+
+// StartImportJob marks a job as running and returns it. A job resumed after
+// a restart keeps its progress.
+func (j *ImportJobs) StartImportJob(id string, collectionName string) (ImportJobs, error) {
+	collection := returnCollectionPointer(collectionName)
+	startedAt := now()
+
+	var job ImportJobs
+	err := collection.FindOneAndUpdate(context.Background(),
+		bson.M{"_id": id, "_status": bson.M{"$in": bson.A{ImportQueued, ImportRunning}}, "_cancelrequested": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"_status": ImportRunning, "_startedat": startedAt}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return ImportJobs{}, fmt.Errorf("import job %s is not waiting to run", id)
+	}
+	if err != nil {
+		log.Println(err)
+		return ImportJobs{}, err
+	}
+	return job, nil
+}
+
+// UpdateImportProgress saves the progress of a running job and reports
+// whether it was asked to stop.
+func (j *ImportJobs) UpdateImportProgress(id string, progress ImportProgress, collectionName string) (bool, error) {
+	collection := returnCollectionPointer(collectionName)
+	if progress.RejectedRows == nil {
+		progress.RejectedRows = []RejectedRows{}
+	}
+
+	var job ImportJobs
+	err := collection.FindOneAndUpdate(context.Background(), bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"_format":        progress.Format,
+		"_totalrows":     progress.TotalRows,
+		"_processedrows": progress.ProcessedRows,
+		"_importedrows":  progress.ImportedRows,
+		"_skippedrows":   progress.SkippedRows,
+		"_rejectedcount": progress.RejectedCount,
+		"_rejectedrows":  progress.RejectedRows,
+		"_orphancount":   progress.OrphanCount,
+	}}).Decode(&job)
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+	return job.CancelRequested, nil
+}
+
+// FinishImportJob records how a job ended and drops its payload.
+func (j *ImportJobs) FinishImportJob(id string, status string, jobErr error, collectionName string) error {
+	collection := returnCollectionPointer(collectionName)
+	finishedAt := now()
+	set := bson.M{"_status": status, "_finishedat": finishedAt}
+	if jobErr != nil {
+		set["_error"] = jobErr.Error()
+	}
+	if _, err := collection.UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": set}); err != nil {
+		log.Println(err)
+		return err
+	}
+
+	bucket, err := payloadBucket(collectionName)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if err = bucket.Delete(id); err != nil && err != gridfs.ErrFileNotFound {
+		log.Println(err)
+		return err
+	}
+	return nil
+}
+
+// CancelImportJob asks a job to stop. A queued job is cancelled right away,
+// a running one stops within a second.
+func (j *ImportJobs) CancelImportJob(id string, collectionName string) (ImportJobs, error) {
+	collection := returnCollectionPointer(collectionName)
+
+	job, err := j.GetImportJob(id, collectionName)
+	if err != nil {
+		return ImportJobs{}, err
+	}
+	if job.IsFinished() {
+		return job, fmt.Errorf("import job already %s", job.Status)
+	}
+
+	_, err = collection.UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": bson.M{"_cancelrequested": true}})
+	if err != nil {
+		log.Println(err)
+		return ImportJobs{}, err
+	}
+	if job.Status == ImportQueued {
+		if err = j.FinishImportJob(id, ImportCancelled, nil, collectionName); err != nil {
+			return ImportJobs{}, err
+		}
+	}
+	return j.GetImportJob(id, collectionName)
+}
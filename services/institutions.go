@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Institutions group every code of a bank by the 4 letter institution code,
+// the first part of a BIC, across the countries it is present in.
+type Institutions struct {
+	InstitutionCode  string   `json:"institutioncode"`
+	BankNames        []string `json:"banknames"`
+	Countries        []string `json:"countries"`
+	HeadquarterCount int      `json:"headquartercount"`
+	BranchCount      int      `json:"branchcount"`
+	// Headquarters is the tree of BIC8s and their branches, it is left out
+	// of institution lists.
+	Headquarters []InstitutionHeadquarters `json:"headquarters,omitempty"`
+}
+
+// InstitutionHeadquarters are the codes sharing a BIC8. Headquarter is nil
+// when only branches of the BIC8 exist.
+type InstitutionHeadquarters struct {
+	Bic8            string                         `json:"bic8"`
+	CountryISO2Code string                         `json:"countryiso2code"`
+	Headquarter     *SwiftCodeArrayElemWithCountry `json:"headquarter"`
+	Branches        []SwiftCodeArrayElem           `json:"branches"`
+}
+
+var institutionCodePattern = regexp.MustCompile(`^[A-Z]{4}$`)
+
+// BuildInstitutions groups swift codes into institutions sorted by their
+// code, with BIC8s and branches sorted by swift code.
+func BuildInstitutions(swiftCodes []SwiftCodes) []Institutions {
+	sorted := append([]SwiftCodes(nil), swiftCodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].SwiftCode < sorted[j].SwiftCode })
+
+	institutions := []Institutions{}
+	for _, swiftCode := range sorted {
+		if len(swiftCode.SwiftCode) != 11 {
+			continue
+		}
+		if len(institutions) == 0 || institutions[len(institutions)-1].InstitutionCode != swiftCode.SwiftCode[:4] {
+			institutions = append(institutions, Institutions{
+				InstitutionCode: swiftCode.SwiftCode[:4],
+				BankNames:       []string{},
+				Countries:       []string{},
+			})
+		}
+		institution := &institutions[len(institutions)-1]
+
+		bic8 := swiftCode.SwiftCode[:8]
+		if len(institution.Headquarters) == 0 || institution.Headquarters[len(institution.Headquarters)-1].Bic8 != bic8 {
+			institution.Headquarters = append(institution.Headquarters, InstitutionHeadquarters{
+				Bic8:            bic8,
+				CountryISO2Code: swiftCode.CountryISO2Code,
+				Branches:        []SwiftCodeArrayElem{},
+			})
+		}
+		headquarters := &institution.Headquarters[len(institution.Headquarters)-1]
+
+		if swiftCode.SwiftCode == bic8+"XXX" {
+			headquarter := swiftCode.arrayElemWithCountry()
+			headquarters.Headquarter = &headquarter
+			institution.HeadquarterCount++
+		} else {
+			headquarters.Branches = append(headquarters.Branches, swiftCode.arrayElem())
+			institution.BranchCount++
+		}
+		institution.BankNames = appendMissing(institution.BankNames, swiftCode.BankName)
+		institution.Countries = appendMissing(institution.Countries, swiftCode.CountryISO2Code)
+	}
+
+	for i := range institutions {
+		sort.Strings(institutions[i].BankNames)
+		sort.Strings(institutions[i].Countries)
+	}
+	return institutions
+}
+
+func appendMissing(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func ValidateInstitutionCode(institutionCode string) error {
+	if !institutionCodePattern.MatchString(institutionCode) {
+		return fmt.Errorf("institution code must be exactly 4 letters")
+	}
+	return nil
+}
+
+// FindInstitution returns the institution with its tree of BIC8s and
+// branches, or mongo.ErrNoDocuments when no code of it is in effect.
+func (t *SwiftCodes) FindInstitution(institutionCode string, opts LookupOptions, collectionName string) (Institutions, error) {
+	if err := ValidateInstitutionCode(institutionCode); err != nil {
+		return Institutions{}, err
+	}
+
+	swiftCodes, err := findSwiftCodes(bson.M{"_swiftcode": bson.M{"$regex": "^" + institutionCode}}, opts, collectionName)
+	if err != nil {
+		return Institutions{}, err
+	}
+	institutions := BuildInstitutions(swiftCodes)
+	if len(institutions) == 0 {
+		return Institutions{}, mongo.ErrNoDocuments
+	}
+	return institutions[0], nil
+}
+
+// FindInstitutions lists every institution without the tree.
+func (t *SwiftCodes) FindInstitutions(opts LookupOptions, collectionName string) ([]Institutions, error) {
+	swiftCodes, err := findSwiftCodes(bson.M{}, opts, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	institutions := BuildInstitutions(swiftCodes)
+	for i := range institutions {
+		institutions[i].Headquarters = nil
+	}
+	return institutions, nil
+}
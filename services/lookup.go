@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LookupOptions select which state of the directory a read sees. The zero
+// value reads the codes in effect now, as currently recorded. AsOf reads the
+// directory as it was recorded at that moment, EffectiveAt picks the codes
+// in effect at another date and defaults to AsOf. Classifications, when
+// given, restrict reads to codes of those classifications. BankName keeps
+// codes whose bank name has words starting with its words and TownName codes
+// in that town, both compared by their SearchKey.
+type LookupOptions struct {
+	AsOf            time.Time
+	EffectiveAt     time.Time
+	IncludeDeleted  bool
+	Classifications []string
+	BankName        string
+	TownName        string
+}
+
+// andFilter adds conditions which must all hold to filter.
+func andFilter(filter bson.M, conditions ...bson.M) bson.M {
+	all, _ := filter["$and"].(bson.A)
+	for _, condition := range conditions {
+		all = append(all, condition)
+	}
+	filter["$and"] = all
+	return filter
+}
+
+// wordPrefix matches the word arrays with a word starting with word. The
+// regex is anchored, so it runs on the index of the array.
+func wordPrefix(word string) bson.M {
+	return bson.M{"$regex": "^" + regexp.QuoteMeta(word)}
+}
+
+// optionsFilter restricts a filter of current codes to the classifications
+// and names of opts, using the stored derived fields.
+func optionsFilter(filter bson.M, opts LookupOptions) bson.M {
+	if len(opts.Classifications) > 0 {
+		filter["_classification"] = bson.M{"$in": opts.Classifications}
+	}
+	if townName := SearchKey(opts.TownName); townName != "" {
+		filter["_townnamekey"] = townName
+	}
+	if bankName := SearchKey(opts.BankName); bankName != "" {
+		var words []bson.M
+		for _, word := range strings.Fields(bankName) {
+			words = append(words, bson.M{"_banknamewords": wordPrefix(word)})
+		}
+		filter = andFilter(filter, words...)
+	}
+	return filter
+}
+
+// fillDerivedFields derives the fields of codes stored before they were
+// derived, e.g. past versions.
+func fillDerivedFields(swiftCodes []SwiftCodes) {
+	for i, swiftCode := range swiftCodes {
+		if swiftCode.Classification == "" || swiftCode.PostalAddress == nil || swiftCode.BankNameKey == "" {
+			swiftCodes[i] = swiftCode.withDerivedFields()
+		}
+	}
+}
+
+// filterSwiftCodes drops the codes not matching opts as optionsFilter does.
+// Reads of past states use it, they can't query the history by those fields.
+func filterSwiftCodes(swiftCodes []SwiftCodes, opts LookupOptions) []SwiftCodes {
+	fillDerivedFields(swiftCodes)
+	bankName := SearchKey(opts.BankName)
+	townName := SearchKey(opts.TownName)
+
+	result := swiftCodes[:0]
+	for _, swiftCode := range swiftCodes {
+		if len(opts.Classifications) > 0 && !containsString(opts.Classifications, swiftCode.Classification) {
+			continue
+		}
+		if bankName != "" && !matchesWords(swiftCode.BankNameKey, bankName) {
+			continue
+		}
+		if townName != "" && swiftCode.TownNameKey != townName {
+			continue
+		}
+		result = append(result, swiftCode)
+	}
+	return result
+}
+
+func findSwiftCodes(filter bson.M, opts LookupOptions, collectionName string) ([]SwiftCodes, error) {
+	return findSwiftCodesWithOptions(filter, opts, options.Find(), collectionName)
+}
+
+// findSwiftCodesWithOptions finds codes like findSwiftCodes, sorting and
+// limiting current codes by findOptions. Past states aren't sorted or
+// limited.
+func findSwiftCodesWithOptions(filter bson.M, opts LookupOptions, findOptions *options.FindOptions, collectionName string) ([]SwiftCodes, error) {
+	if !opts.IncludeDeleted {
+		filter = notDeleted(filter)
+	}
+
+	effectiveAt := opts.EffectiveAt
+	if effectiveAt.IsZero() {
+		effectiveAt = opts.AsOf
+	}
+	if effectiveAt.IsZero() {
+		effectiveAt = now()
+	}
+	filter = effectiveFilter(filter, effectiveAt)
+
+	if !opts.AsOf.IsZero() {
+		swiftCodes, err := findSwiftCodesAsOf(filter, opts.AsOf, collectionName)
+		if err != nil {
+			return nil, err
+		}
+		return filterSwiftCodes(swiftCodes, opts), nil
+	}
+
+	collection := returnCollectionPointer(collectionName)
+	swiftCodes := []SwiftCodes{}
+
+	cursor, err := collection.Find(context.Background(), optionsFilter(filter, opts), findOptions)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if err = cursor.All(context.Background(), &swiftCodes); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	fillDerivedFields(swiftCodes)
+	return swiftCodes, nil
+}
+
+func (t *SwiftCodes) FindSwiftCode(swiftCodeName string, opts LookupOptions, collectionName string) (SwiftCodes, error) {
+	swiftCodes, err := findSwiftCodes(bson.M{"_swiftcode": swiftCodeName}, opts, collectionName)
+	if err != nil {
+		return SwiftCodes{}, err
+	}
+	if len(swiftCodes) == 0 {
+		return SwiftCodes{}, mongo.ErrNoDocuments
+	}
+	return swiftCodes[0], nil
+}
+
+func (t *SwiftCodes) FindSwiftCodes(opts LookupOptions, collectionName string) ([]SwiftCodes, error) {
+	return findSwiftCodes(bson.M{}, opts, collectionName)
+}
+
+func (t *SwiftCodes) FindBranches(prefix string, opts LookupOptions, collectionName string) ([]SwiftCodeArrayElem, error) {
+	swiftCodes, err := findSwiftCodes(branchesFilter(prefix), opts, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []SwiftCodeArrayElem{}
+	for _, swiftCode := range swiftCodes {
+		branches = append(branches, swiftCode.arrayElem())
+	}
+	return branches, nil
+}
+
+func (t *SwiftCodes) FindSwiftCodesByISOCode(isoCode string, opts LookupOptions, collectionName string) ([]SwiftCodeArrayElemWithCountry, error) {
+	swiftCodes, err := findSwiftCodes(bson.M{"_countryiso2code": isoCode}, opts, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []SwiftCodeArrayElemWithCountry{}
+	for _, swiftCode := range swiftCodes {
+		result = append(result, swiftCode.arrayElemWithCountry())
+	}
+	return result, nil
+}
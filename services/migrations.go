@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migrations record the backfills already run on a swift codes collection.
+type Migrations struct {
+	Name      string    `bson:"_id"`
+	AppliedAt time.Time `bson:"_appliedat"`
+}
+
+// migrations fill in data stored before it was derived, each once per
+// collection and in order. They scan the whole directory, so they don't run
+// on every start; a change to derived data needs a migration of a new name.
+var migrations = []struct {
+	name string
+	run  func(ctx context.Context, t *SwiftCodes, collectionName string) error
+}{
+	{name: "search-word-keys", run: func(ctx context.Context, t *SwiftCodes, collectionName string) error {
+		if _, err := t.UpdateDerivedFields(ctx, collectionName); err != nil {
+			return err
+		}
+		return t.EnsureIndexes(ctx, collectionName)
+	}},
+	{name: "place-keys", run: func(ctx context.Context, t *SwiftCodes, collectionName string) error {
+		rekeyed, err := t.UpdatePlaceKeys(ctx, collectionName)
+		if err != nil || rekeyed == 0 {
+			return err
+		}
+		_, err = t.LocateSwiftCodes(ctx, collectionName)
+		return err
+	}},
+}
+
+func migrationsCollectionName(collectionName string) string {
+	return collectionName + "_migrations"
+}
+
+// RunMigrations runs the migrations collectionName hasn't had yet. A failed
+// migration runs again on the next call.
+func (t *SwiftCodes) RunMigrations(ctx context.Context, collectionName string) error {
+	collection := returnCollectionPointer(migrationsCollectionName(collectionName))
+
+	for _, migration := range migrations {
+		err := collection.FindOne(ctx, bson.M{"_id": migration.name}).Err()
+		if err == nil {
+			continue
+		}
+		if err != mongo.ErrNoDocuments {
+			log.Println(err)
+			return err
+		}
+
+		log.Printf("migrating %s: %s", collectionName, migration.name)
+		if err = migration.run(ctx, t, collectionName); err != nil {
+			return err
+		}
+		if _, err = collection.InsertOne(ctx, Migrations{Name: migration.name, AppliedAt: now()}); err != nil {
+			log.Println(err)
+			return err
+		}
+	}
+	return nil
+}
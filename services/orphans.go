@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const OperationReconcile = "reconcile"
+
+// OrphanPolicies decide what happens to imported branches whose headquarter
+// is in neither the directory file nor the collection.
+type OrphanPolicies string
+
+const (
+	// OrphanFlag imports the branch and counts it as an orphan.
+	OrphanFlag OrphanPolicies = "flag"
+	// OrphanReject refuses the branch.
+	OrphanReject OrphanPolicies = "reject"
+	// OrphanSynthesize creates a placeholder headquarter from the branch.
+	OrphanSynthesize OrphanPolicies = "synthesize"
+)
+
+// OrphanRemovalReason is recorded on orphans deleted by a reconciliation.
+const OrphanRemovalReason = "headquarter missing"
+
+func ParseOrphanPolicy(value string) (OrphanPolicies, error) {
+	switch policy := OrphanPolicies(value); policy {
+	case OrphanFlag, OrphanReject, OrphanSynthesize:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown orphan branch policy %q, use flag, reject or synthesize", value)
+}
+
+// OrphanReports list branches without a headquarter and the placeholder
+// headquarters standing in for missing ones.
+type OrphanReports struct {
+	Branches     []SwiftCodeArrayElem `json:"branches"`
+	Placeholders []SwiftCodeArrayElem `json:"placeholders"`
+}
+
+// placeholderHeadquarter is the headquarter synthesized for an orphaned
+// branch. It takes the bank and country data of the branch but no address.
+func placeholderHeadquarter(branch SwiftCodes) SwiftCodes {
+	return SwiftCodes{
+		SwiftCode:       branch.SwiftCode[:8] + "XXX",
+		CountryISO2Code: branch.CountryISO2Code,
+		CodeType:        branch.CodeType,
+		BankName:        branch.BankName,
+		TownName:        branch.TownName,
+		CountryName:     branch.CountryName,
+		TimeZone:        branch.TimeZone,
+		IsHeadQuater:    true,
+		Placeholder:     true,
+	}
+}
+
+// isHeadquarterDeleted tells whether the headquarter of prefix was soft
+// deleted.
+func isHeadquarterDeleted(ctx context.Context, prefix string, collectionName string) (bool, error) {
+	collection := returnCollectionPointer(collectionName)
+	count, err := collection.CountDocuments(ctx, bson.M{"_swiftcode": prefix + "XXX", "_deletedat": bson.M{"$exists": true}})
+	if err != nil {
+		log.Println(err)
+		return false, err
+	}
+	return count != 0, nil
+}
+
+// CheckHeadquarter applies policy to a branch about to be imported and
+// reports whether its headquarter is missing. Under OrphanReject a missing
+// headquarter is an error, under OrphanSynthesize a placeholder is inserted.
+// A deleted headquarter counts as missing but is never replaced by a
+// placeholder, the deletion stands and the branch is only flagged.
+func (t *SwiftCodes) CheckHeadquarter(ctx context.Context, branch SwiftCodes, policy OrphanPolicies, collectionName string) (bool, error) {
+	if branch.IsHeadQuater || len(branch.SwiftCode) != 11 {
+		return false, nil
+	}
+	prefix := branch.SwiftCode[:8]
+	_, err := t.GetHeadquater(prefix, collectionName)
+	if err == nil {
+		return false, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	deleted, err := isHeadquarterDeleted(ctx, prefix, collectionName)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case policy == OrphanReject && deleted:
+		return true, fmt.Errorf("headquarter %sXXX of the branch was deleted", prefix)
+	case policy == OrphanReject:
+		return true, fmt.Errorf("headquarter %sXXX of the branch doesn't exist", prefix)
+	case policy == OrphanSynthesize && !deleted:
+		return true, t.ImportSwiftCode(ctx, placeholderHeadquarter(branch), collectionName)
+	}
+	return true, nil
+}
+
+// ReplacePlaceholder gives a placeholder headquarter the data of the real
+// one once it is imported.
+func (t *SwiftCodes) ReplacePlaceholder(ctx context.Context, swiftCode SwiftCodes, collectionName string) error {
+	countryName, err := ValidateCountry(swiftCode.CountryISO2Code, swiftCode.CountryName)
+	if err != nil {
+		return err
+	}
+	timeZone, err := ValidateTimeZone(swiftCode.TimeZone, swiftCode.CountryISO2Code)
+	if err != nil {
+		return err
+	}
+	if err = validateValidity(swiftCode.ValidFrom, swiftCode.ValidTo); err != nil {
+		return err
+	}
+
+	update := validityUpdate(swiftCode.ValidFrom, swiftCode.ValidTo)
+	set := update["$set"].(bson.M)
+	set["_countryiso2code"] = swiftCode.CountryISO2Code
+	set["_codetype"] = swiftCode.CodeType
+	set["_bankname"] = swiftCode.BankName
+	set["_address"] = swiftCode.Address
+	set["_postaladdress"] = ParseAddress(swiftCode.Address, swiftCode.TownName, swiftCode.CountryISO2Code)
+	set["_townname"] = swiftCode.TownName
+	setSearchKeys(set, SearchKey(swiftCode.BankName), SearchKey(swiftCode.TownName))
+	set["_countryname"] = countryName
+	set["_timezone"] = timeZone
+	unset := update["$unset"].(bson.M)
+	unset["_placeholder"] = ""
+	location, err := findLocation(ctx, swiftCode.CountryISO2Code, swiftCode.TownName, collectionName)
+	if err != nil {
+		return err
+	}
+	locationUpdate(update, location)
+	_, _, err = changeSwiftCode(ctx, notDeleted(bson.M{"_swiftcode": swiftCode.SwiftCode, "_placeholder": true}), update, OperationImport, collectionName)
+	return err
+}
+
+// GetOrphanReport lists the current branches without a current headquarter
+// and the placeholder headquarters.
+func (t *SwiftCodes) GetOrphanReport(collectionName string) (OrphanReports, error) {
+	report := OrphanReports{
+		Branches:     []SwiftCodeArrayElem{},
+		Placeholders: []SwiftCodeArrayElem{},
+	}
+
+	orphans, placeholders, err := findOrphans(collectionName)
+	if err != nil {
+		return report, err
+	}
+	for _, orphan := range orphans {
+		report.Branches = append(report.Branches, orphan.arrayElem())
+	}
+	for _, placeholder := range placeholders {
+		report.Placeholders = append(report.Placeholders, placeholder.arrayElem())
+	}
+	return report, nil
+}
+
+func findOrphans(collectionName string) ([]SwiftCodes, []SwiftCodes, error) {
+	swiftCodes, err := findSwiftCodes(bson.M{}, LookupOptions{}, collectionName)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(swiftCodes, func(i, j int) bool { return swiftCodes[i].SwiftCode < swiftCodes[j].SwiftCode })
+
+	headquarters := map[string]bool{}
+	for _, swiftCode := range swiftCodes {
+		if swiftCode.IsHeadQuater && len(swiftCode.SwiftCode) == 11 {
+			headquarters[swiftCode.SwiftCode[:8]] = true
+		}
+	}
+
+	var orphans, placeholders []SwiftCodes
+	for _, swiftCode := range swiftCodes {
+		if swiftCode.Placeholder {
+			placeholders = append(placeholders, swiftCode)
+		}
+		if !swiftCode.IsHeadQuater && len(swiftCode.SwiftCode) == 11 && !headquarters[swiftCode.SwiftCode[:8]] {
+			orphans = append(orphans, swiftCode)
+		}
+	}
+	return orphans, placeholders, nil
+}
+
+// ReconcileOrphanBranches resolves the orphans already stored: OrphanSynthesize
+// creates one placeholder per missing headquarter, except for deleted ones,
+// OrphanReject deletes the orphans. It returns the number of codes created
+// or deleted.
+func (t *SwiftCodes) ReconcileOrphanBranches(ctx context.Context, policy OrphanPolicies, collectionName string) (int, error) {
+	if policy != OrphanSynthesize && policy != OrphanReject {
+		return 0, fmt.Errorf("orphans can only be reconciled with the synthesize or reject policy")
+	}
+
+	orphans, _, err := findOrphans(collectionName)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	synthesized := map[string]bool{}
+	for _, orphan := range orphans {
+		if policy == OrphanReject {
+			if err = t.DeleteSwiftCode(ctx, orphan.SwiftCode, OrphanRemovalReason, collectionName); err != nil {
+				return changed, err
+			}
+			changed++
+			continue
+		}
+		if synthesized[orphan.SwiftCode[:8]] {
+			continue
+		}
+		synthesized[orphan.SwiftCode[:8]] = true
+		deleted, err := isHeadquarterDeleted(ctx, orphan.SwiftCode[:8], collectionName)
+		if err != nil {
+			return changed, err
+		}
+		if deleted {
+			continue
+		}
+		if err = t.insertSwiftCode(ctx, placeholderHeadquarter(orphan), collectionName, OperationReconcile); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations spell upper case Cyrillic and Greek letters, and Latin
+// letters which don't decompose into a base letter and an accent, in A to Z.
+var transliterations = map[rune]string{
+	// Cyrillic
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Ґ': "G", 'Д': "D", 'Е': "E", 'Ё': "E",
+	'Є': "YE", 'Ж': "ZH", 'З': "Z", 'И': "I", 'І': "I", 'Ї': "YI", 'Й': "Y", 'К': "K",
+	'Л': "L", 'М': "M", 'Н': "N", 'О': "O", 'П': "P", 'Р': "R", 'С': "S", 'Т': "T",
+	'У': "U", 'Ў': "U", 'Ф': "F", 'Х': "KH", 'Ц': "TS", 'Ч': "CH", 'Ш': "SH", 'Щ': "SHCH",
+	'Ъ': "", 'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "YU", 'Я': "YA",
+	'Ђ': "DJ", 'Ј': "J", 'Љ': "LJ", 'Њ': "NJ", 'Ћ': "C", 'Џ': "DZ", 'Ѓ': "GJ", 'Ќ': "KJ", 'Ѕ': "DZ",
+	// Greek
+	'Α': "A", 'Β': "V", 'Γ': "G", 'Δ': "D", 'Ε': "E", 'Ζ': "Z", 'Η': "I", 'Θ': "TH",
+	'Ι': "I", 'Κ': "K", 'Λ': "L", 'Μ': "M", 'Ν': "N", 'Ξ': "X", 'Ο': "O", 'Π': "P",
+	'Ρ': "R", 'Σ': "S", 'Τ': "T", 'Υ': "Y", 'Φ': "F", 'Χ': "CH", 'Ψ': "PS", 'Ω': "O",
+	// Latin
+	'Ł': "L", 'Ø': "O", 'Đ': "D", 'Ð': "D", 'Ħ': "H", 'Ŧ': "T", 'Ŋ': "N",
+	'ß': "SS", 'Æ': "AE", 'Œ': "OE", 'Þ': "TH",
+}
+
+func transliterate(value string) string {
+	var builder strings.Builder
+	for _, r := range value {
+		if replacement, ok := transliterations[r]; ok {
+			builder.WriteString(replacement)
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// SearchKey folds a name for matching: letters are upper cased and spelled
+// in A to Z, accents dropped and anything else but digits turned into single
+// spaces, so "Łódź", "LODZ" and "Lodz" share the key "LODZ". Letters are
+// transliterated before accents are dropped, which keeps "Й" apart from "И",
+// and again after, which catches accented Greek letters.
+func SearchKey(value string) string {
+	key := transliterate(strings.ToUpper(norm.NFC.String(value)))
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), key)
+	if err == nil {
+		key = stripped
+	}
+	key = transliterate(key)
+
+	return strings.Join(strings.FieldsFunc(key, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9')
+	}), " ")
+}
+
+// setSearchKeys sets the search keys of the names and their words.
+func setSearchKeys(set bson.M, bankNameKey string, townNameKey string) {
+	set["_banknamekey"] = bankNameKey
+	set["_townnamekey"] = townNameKey
+	set["_banknamewords"] = strings.Fields(bankNameKey)
+	set["_townnamewords"] = strings.Fields(townNameKey)
+}
+
+func sameWords(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesWords tells whether every word of query starts a word of key.
+func matchesWords(key string, query string) bool {
+	words := strings.Fields(key)
+	for _, queryWord := range strings.Fields(query) {
+		found := false
+		for _, word := range words {
+			if strings.HasPrefix(word, queryWord) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesSearch tells whether a code starts with the query key, or whether
+// its bank and town names together have words starting with every word of
+// it.
+func matchesSearch(swiftCode SwiftCodes, key string) bool {
+	return strings.HasPrefix(swiftCode.SwiftCode, strings.ReplaceAll(key, " ", "")) ||
+		matchesWords(swiftCode.BankNameKey+" "+swiftCode.TownNameKey, key)
+}
+
+// searchFilter is matchesSearch as a query on the stored search keys.
+func searchFilter(key string) bson.M {
+	var words bson.A
+	for _, word := range strings.Fields(key) {
+		words = append(words, bson.M{"$or": bson.A{
+			bson.M{"_banknamewords": wordPrefix(word)},
+			bson.M{"_townnamewords": wordPrefix(word)},
+		}})
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"_swiftcode": bson.M{"$regex": "^" + regexp.QuoteMeta(strings.ReplaceAll(key, " ", ""))}},
+		bson.M{"$and": words},
+	}}
+}
+
+// SearchSwiftCodes finds the codes starting with query, or whose bank and
+// town names together have words starting with every word of query. It
+// returns up to limit codes sorted by swift code.
+func (t *SwiftCodes) SearchSwiftCodes(query string, limit int, opts LookupOptions, collectionName string) ([]SwiftCodes, error) {
+	result := []SwiftCodes{}
+	key := SearchKey(query)
+	if key == "" {
+		return result, nil
+	}
+
+	if opts.AsOf.IsZero() {
+		findOptions := options.Find().SetSort(bson.D{{Key: "_swiftcode", Value: 1}})
+		if limit > 0 {
+			findOptions.SetLimit(int64(limit))
+		}
+		return findSwiftCodesWithOptions(searchFilter(key), opts, findOptions, collectionName)
+	}
+
+	// Past states are matched here, the history can't be queried by keys.
+	swiftCodes, err := findSwiftCodes(bson.M{}, opts, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	for _, swiftCode := range swiftCodes {
+		if matchesSearch(swiftCode, key) {
+			result = append(result, swiftCode)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].SwiftCode < result[j].SwiftCode })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	OperationRestore = "restore"
+	OperationPurge   = "purge"
+)
+
+func (t *SwiftCodes) RestoreSwiftCode(ctx context.Context, swiftCodeName string, collectionName string) error {
+	filter := bson.M{"_swiftcode": swiftCodeName, "_deletedat": bson.M{"$exists": true}}
+	update := bson.M{"$unset": bson.M{"_deletedat": "", "_deletereason": ""}}
+	_, _, err := changeSwiftCode(ctx, filter, update, OperationRestore, collectionName)
+	return err
+}
+
+// IsSwiftCodeDeleted reports whether the code is soft deleted and waiting
+// to be purged.
+func (t *SwiftCodes) IsSwiftCodeDeleted(swiftCodeName string, collectionName string) bool {
+	collection := returnCollectionPointer(collectionName)
+	count, err := collection.CountDocuments(context.Background(), bson.M{"_swiftcode": swiftCodeName, "_deletedat": bson.M{"$exists": true}})
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return count != 0
+}
+
+// PurgeDeletedSwiftCodes removes codes deleted before the cutoff for good.
+// Their versions stay in the history.
+func (t *SwiftCodes) PurgeDeletedSwiftCodes(ctx context.Context, cutoff time.Time, collectionName string) (int64, error) {
+	collection := returnCollectionPointer(collectionName)
+	end, err := beginWrite(ctx, collectionName)
+	if err != nil {
+		return 0, err
+	}
+	defer end()
+
+	cursor, err := collection.Find(ctx, bson.M{"_deletedat": bson.M{"$lt": cutoff}})
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	var swiftCodes []SwiftCodes
+	if err = cursor.All(ctx, &swiftCodes); err != nil {
+		log.Println(err)
+		return 0, err
+	}
+
+	var purged int64
+	for _, swiftCode := range swiftCodes {
+		result, err := collection.DeleteOne(ctx, bson.M{"_swiftcode": swiftCode.SwiftCode, "_version": swiftCode.Version})
+		if err != nil {
+			log.Println(err)
+			return purged, err
+		}
+		if result.DeletedCount == 0 {
+			continue
+		}
+		if err = archiveVersion(ctx, swiftCode, now(), OperationPurge, collectionName); err != nil {
+			return purged, err
+		}
+		if err = appendAuditEntry(ctx, OperationPurge, swiftCode.SwiftCode, &swiftCode, nil, collectionName); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// RunPurgeJob purges codes deleted longer than retention ago every interval
+// until the context is cancelled.
+func (t *SwiftCodes) RunPurgeJob(ctx context.Context, retention time.Duration, interval time.Duration, collectionName string) {
+	ctx = WithAuditContext(ctx, AuditContext{Actor: "system:purge"})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := t.PurgeDeletedSwiftCodes(ctx, now().Add(-retention), collectionName)
+		if err != nil {
+			log.Println("Error while purging deleted swift codes", err)
+		} else if purged != 0 {
+			log.Printf("Purged %d deleted swift codes", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
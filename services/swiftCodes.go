@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"regexp"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type SwiftCodes struct {
@@ -19,6 +22,36 @@ type SwiftCodes struct {
 	CountryName     string `json:"countryname" bson:"_countryname" csv:"COUNTRY NAME"`
 	TimeZone        string `json:"timezone,omitempty" bson:"_timezone,omitempty" csv:"TIME ZONE"`
 	IsHeadQuater    bool   `json:"isheadquater" bson:"_isheadquater"  csv:"IS HEADQUATER"`
+	// Classification is derived from the swift code on insert, see
+	// ClassifySwiftCode.
+	Classification string `json:"classification,omitempty" bson:"_classification,omitempty" csv:"-"`
+	// PostalAddress is parsed from Address on insert, see ParseAddress.
+	PostalAddress *PostalAddresses `json:"postaladdress,omitempty" bson:"_postaladdress,omitempty" csv:"-"`
+	// BankNameKey and TownNameKey are the names folded for matching, see
+	// SearchKey.
+	BankNameKey string `json:"-" bson:"_banknamekey,omitempty" csv:"-"`
+	TownNameKey string `json:"-" bson:"_townnamekey,omitempty" csv:"-"`
+	// BankNameWords and TownNameWords are the words of the keys, which
+	// queries match by prefix through their indexes.
+	BankNameWords []string `json:"-" bson:"_banknamewords,omitempty" csv:"-"`
+	TownNameWords []string `json:"-" bson:"_townnamewords,omitempty" csv:"-"`
+	// Location comes from the gazetteer entry of the town, see Places.
+	Location *GeoPoints `json:"location,omitempty" bson:"_location,omitempty" csv:"-"`
+	// Version starts at 1 and grows with every change; previous versions
+	// are kept in the history collection.
+	Version   int       `json:"version" bson:"_version" csv:"-"`
+	UpdatedAt time.Time `json:"updatedat" bson:"_updatedat" csv:"-"`
+	// Deleted codes stay in the collection until they are purged, but are
+	// hidden from reads.
+	DeletedAt    *time.Time `json:"deletedat,omitempty" bson:"_deletedat,omitempty" csv:"-"`
+	DeleteReason string     `json:"deletereason,omitempty" bson:"_deletereason,omitempty" csv:"-"`
+	// ValidFrom and ValidTo bound when the code is in effect, open ends mean
+	// it always was or stays in effect.
+	ValidFrom *time.Time `json:"validfrom,omitempty" bson:"_validfrom,omitempty" csv:"-"`
+	ValidTo   *time.Time `json:"validto,omitempty" bson:"_validto,omitempty" csv:"-"`
+	// Placeholder marks a headquarter synthesized for orphaned branches,
+	// see OrphanSynthesize.
+	Placeholder bool `json:"placeholder,omitempty" bson:"_placeholder,omitempty" csv:"-"`
 }
 
 type SwiftCodeArrayElem struct {
@@ -27,6 +60,7 @@ type SwiftCodeArrayElem struct {
 	CountryISO2Code string
 	IsHeadQuater    bool
 	SwiftCode       string
+	Classification  string `json:",omitempty"`
 }
 type SwiftCodeArrayElemWithCountry struct {
 	Address         string
@@ -35,6 +69,64 @@ type SwiftCodeArrayElemWithCountry struct {
 	IsHeadQuater    bool
 	SwiftCode       string
 	CountryName     string
+	Classification  string `json:",omitempty"`
+}
+
+func (s SwiftCodes) arrayElem() SwiftCodeArrayElem {
+	return SwiftCodeArrayElem{
+		Address:         s.Address,
+		BankName:        s.BankName,
+		CountryISO2Code: s.CountryISO2Code,
+		IsHeadQuater:    s.IsHeadQuater,
+		SwiftCode:       s.SwiftCode,
+		Classification:  s.Classification,
+	}
+}
+
+func (s SwiftCodes) arrayElemWithCountry() SwiftCodeArrayElemWithCountry {
+	return SwiftCodeArrayElemWithCountry{
+		Address:         s.Address,
+		BankName:        s.BankName,
+		CountryISO2Code: s.CountryISO2Code,
+		IsHeadQuater:    s.IsHeadQuater,
+		SwiftCode:       s.SwiftCode,
+		CountryName:     s.CountryName,
+		Classification:  s.Classification,
+	}
+}
+
+// notDeleted restricts a filter to codes which weren't soft deleted.
+func notDeleted(filter bson.M) bson.M {
+	filter["_deletedat"] = bson.M{"$exists": false}
+	return filter
+}
+
+func branchesFilter(prefix string) bson.M {
+	return bson.M{"_swiftcode": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix), "$ne": prefix + "XXX"}}
+}
+
+// swiftCodeIndexes are the indexes of a swift codes collection: the
+// 2dsphere index near searches need, the town key matched as a whole and
+// the words of the names matched by prefix.
+var swiftCodeIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "_location", Value: "2dsphere"}}},
+	{Keys: bson.D{{Key: "_townnamekey", Value: 1}}},
+	{Keys: bson.D{{Key: "_banknamewords", Value: 1}}},
+	{Keys: bson.D{{Key: "_townnamewords", Value: 1}}},
+}
+
+func createIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateMany(ctx, swiftCodeIndexes)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// EnsureIndexes creates the indexes of collectionName which are missing. A
+// sync creates them on the collection replacing the directory.
+func (t *SwiftCodes) EnsureIndexes(ctx context.Context, collectionName string) error {
+	return createIndexes(ctx, returnCollectionPointer(collectionName))
 }
 
 var client *mongo.Client
@@ -50,43 +142,114 @@ func returnCollectionPointer(collection string) *mongo.Collection {
 	return client.Database(db_name).Collection(collection)
 }
 
-func (s *SwiftCodes) InsertSwiftCode(swiftCode SwiftCodes, collectionName string) error {
+func (s *SwiftCodes) InsertSwiftCode(ctx context.Context, swiftCode SwiftCodes, collectionName string) error {
+	return s.insertSwiftCode(ctx, swiftCode, collectionName, OperationCreate)
+}
+
+// ImportSwiftCode inserts a code coming from a directory file. It differs
+// from InsertSwiftCode only in how the change is audited.
+func (s *SwiftCodes) ImportSwiftCode(ctx context.Context, swiftCode SwiftCodes, collectionName string) error {
+	return s.insertSwiftCode(ctx, swiftCode, collectionName, OperationImport)
+}
+
+func (s *SwiftCodes) insertSwiftCode(ctx context.Context, swiftCode SwiftCodes, collectionName string, operation string) error {
 	collection := returnCollectionPointer(collectionName)
 
 	if len(swiftCode.SwiftCode) != 11 {
 		return fmt.Errorf("swift code must be exactly 11 characters")
 	}
+	countryName, err := ValidateCountry(swiftCode.CountryISO2Code, swiftCode.CountryName)
+	if err != nil {
+		return err
+	}
+	timeZone, err := ValidateTimeZone(swiftCode.TimeZone, swiftCode.CountryISO2Code)
+	if err != nil {
+		return err
+	}
 
-	if len(swiftCode.CountryISO2Code) != 2 {
-		return fmt.Errorf("iso2 code must be exactly 2 characters")
+	if err = validateValidity(swiftCode.ValidFrom, swiftCode.ValidTo); err != nil {
+		return err
 	}
 
+	end, err := beginWrite(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+	defer end()
 	if swiftCode.IsSwiftCodeInDatabase(swiftCode.SwiftCode, collectionName) {
 		return fmt.Errorf("swift code with such name exists")
 	}
 
-	_, err := collection.InsertOne(context.TODO(), SwiftCodes{
+	document := SwiftCodes{
 		SwiftCode:       swiftCode.SwiftCode,
 		CountryISO2Code: swiftCode.CountryISO2Code,
 		CodeType:        swiftCode.CodeType,
 		BankName:        swiftCode.BankName,
 		Address:         swiftCode.Address,
 		TownName:        swiftCode.TownName,
-		CountryName:     swiftCode.CountryName,
-		TimeZone:        swiftCode.TimeZone,
+		CountryName:     countryName,
+		TimeZone:        timeZone,
 		IsHeadQuater:    swiftCode.IsHeadQuater,
-	})
+		UpdatedAt:       now(),
+		ValidFrom:       swiftCode.ValidFrom,
+		ValidTo:         swiftCode.ValidTo,
+		Placeholder:     swiftCode.Placeholder,
+	}.withDerivedFields()
+	if document.Location, err = findLocation(ctx, document.CountryISO2Code, document.TownName, collectionName); err != nil {
+		return err
+	}
+
+	// A soft deleted code with the same name is replaced, its old state
+	// stays in the history.
+	var deleted SwiftCodes
+	err = collection.FindOne(ctx, bson.M{"_swiftcode": swiftCode.SwiftCode}).Decode(&deleted)
+	if err == nil {
+		document.Version = deleted.Version + 1
+		_, err = collection.ReplaceOne(ctx, bson.M{"_swiftcode": swiftCode.SwiftCode, "_version": deleted.Version}, document)
+		if err != nil {
+			log.Println("Error", err)
+			return err
+		}
+		if err = archiveVersion(ctx, deleted, document.UpdatedAt, operation, collectionName); err != nil {
+			undoChange(ctx, deleted, document, false, collectionName)
+			return err
+		}
+		if err = appendAuditEntry(ctx, operation, document.SwiftCode, &deleted, &document, collectionName); err != nil {
+			undoChange(ctx, deleted, document, true, collectionName)
+			return err
+		}
+		return nil
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Println(err)
+		return err
+	}
+
+	document.Version, err = nextVersion(ctx, swiftCode.SwiftCode, collectionName)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	_, err = collection.InsertOne(ctx, document)
 	if err != nil {
 		log.Println("Error", err)
 		return err
 	}
-	return err
+	if err = appendAuditEntry(ctx, operation, document.SwiftCode, nil, &document, collectionName); err != nil {
+		// An unaudited code mustn't stay.
+		if _, deleteErr := collection.DeleteOne(ctx, bson.M{"_swiftcode": document.SwiftCode, "_version": document.Version}); deleteErr != nil {
+			log.Println("Error while undoing insert of", document.SwiftCode, deleteErr)
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *SwiftCodes) GetSwiftCodeBySwiftCodeName(swiftCodeName string, collectionName string) (SwiftCodes, error) {
 	collection := returnCollectionPointer(collectionName)
 	var swiftCode SwiftCodes
-	err := collection.FindOne(context.Background(), bson.M{"_swiftcode": swiftCodeName}).Decode(&swiftCode)
+	err := collection.FindOne(context.Background(), notDeleted(bson.M{"_swiftcode": swiftCodeName})).Decode(&swiftCode)
 	if err != nil {
 		log.Println(err)
 		return SwiftCodes{}, err
@@ -104,7 +267,7 @@ func (s *SwiftCodes) IsSwiftCodeInDatabase(swiftCodeName string, collectionName
 func (s *SwiftCodes) GetHeadquater(swiftCodePrefix string, collectionName string) (SwiftCodes, error) {
 	collection := returnCollectionPointer(collectionName)
 	var swiftCode SwiftCodes
-	err := collection.FindOne(context.Background(), bson.M{"_swiftcode": swiftCodePrefix + "XXX"}).Decode(&swiftCode)
+	err := collection.FindOne(context.Background(), notDeleted(bson.M{"_swiftcode": swiftCodePrefix + "XXX"})).Decode(&swiftCode)
 	if err != nil {
 		log.Println(err)
 		return SwiftCodes{}, err
@@ -116,7 +279,7 @@ func (t *SwiftCodes) GetAllSwiftCodes(collectionName string) ([]SwiftCodes, erro
 	collection := returnCollectionPointer(collectionName)
 	var swiftCodes []SwiftCodes
 
-	cursor, err := collection.Find(context.TODO(), bson.D{})
+	cursor, err := collection.Find(context.TODO(), notDeleted(bson.M{}))
 	if err != nil {
 		log.Fatal(err)
 		return nil, err
@@ -137,8 +300,7 @@ func (t *SwiftCodes) GetAllBranchersWithPrefix(prefix string, collectionName str
 	collection := returnCollectionPointer(collectionName)
 	var swiftCodes []SwiftCodeArrayElem
 
-	filter := bson.M{"_swiftcode": bson.M{"$regex": "^" + prefix, "$ne": prefix + "XXX"}}
-	cursor, err := collection.Find(context.TODO(), filter)
+	cursor, err := collection.Find(context.TODO(), notDeleted(branchesFilter(prefix)))
 
 	if err != nil {
 		log.Fatal(err)
@@ -150,13 +312,7 @@ func (t *SwiftCodes) GetAllBranchersWithPrefix(prefix string, collectionName str
 	for cursor.Next(context.Background()) {
 		var swiftCode SwiftCodes
 		cursor.Decode(&swiftCode)
-		swiftCodes = append(swiftCodes, SwiftCodeArrayElem{
-			Address:         swiftCode.Address,
-			BankName:        swiftCode.BankName,
-			CountryISO2Code: swiftCode.CountryISO2Code,
-			IsHeadQuater:    swiftCode.IsHeadQuater,
-			SwiftCode:       swiftCode.SwiftCode,
-		})
+		swiftCodes = append(swiftCodes, swiftCode.arrayElem())
 	}
 
 	return swiftCodes, nil
@@ -166,7 +322,7 @@ func (t *SwiftCodes) GetAllSwiftCoidesByISOCode(prefix string, collectionName st
 	collection := returnCollectionPointer(collectionName)
 	var swiftCodes []SwiftCodeArrayElemWithCountry
 
-	cursor, err := collection.Find(context.TODO(), bson.M{"_countryiso2code": prefix})
+	cursor, err := collection.Find(context.TODO(), notDeleted(bson.M{"_countryiso2code": prefix}))
 
 	if err != nil {
 		log.Fatal(err)
@@ -178,31 +334,170 @@ func (t *SwiftCodes) GetAllSwiftCoidesByISOCode(prefix string, collectionName st
 	for cursor.Next(context.Background()) {
 		var swiftCode SwiftCodes
 		cursor.Decode(&swiftCode)
-		swiftCodes = append(swiftCodes, SwiftCodeArrayElemWithCountry{
-			Address:         swiftCode.Address,
-			BankName:        swiftCode.BankName,
-			CountryISO2Code: swiftCode.CountryISO2Code,
-			IsHeadQuater:    swiftCode.IsHeadQuater,
-			SwiftCode:       swiftCode.SwiftCode,
-			CountryName:     swiftCode.CountryName,
-		})
+		swiftCodes = append(swiftCodes, swiftCode.arrayElemWithCountry())
 	}
 
 	return swiftCodes, nil
 }
 
-func (t *SwiftCodes) DeleteSwiftCode(swiftCodeName string, collectionName string) error {
+// DeleteSwiftCode marks the code as deleted. It is purged for good once the
+// retention period passes, see PurgeDeletedSwiftCodes.
+func (t *SwiftCodes) DeleteSwiftCode(ctx context.Context, swiftCodeName string, reason string, collectionName string) error {
+	set := bson.M{"_deletedat": now()}
+	if reason != "" {
+		set["_deletereason"] = reason
+	}
+	_, _, err := changeSwiftCode(ctx, notDeleted(bson.M{"_swiftcode": swiftCodeName}), bson.M{"$set": set}, OperationDelete, collectionName)
+	return err
+}
+
+func (t *SwiftCodes) UpdateSwiftCode(ctx context.Context, swiftCodeName string, swiftCode SwiftCodes, collectionName string) error {
+	countryName, err := ValidateCountry(swiftCode.CountryISO2Code, swiftCode.CountryName)
+	if err != nil {
+		return err
+	}
+	timeZone, err := ValidateTimeZone(swiftCode.TimeZone, swiftCode.CountryISO2Code)
+	if err != nil {
+		return err
+	}
+
+	if err = validateValidity(swiftCode.ValidFrom, swiftCode.ValidTo); err != nil {
+		return err
+	}
+
+	update := validityUpdate(swiftCode.ValidFrom, swiftCode.ValidTo)
+	set := update["$set"].(bson.M)
+	set["_countryiso2code"] = swiftCode.CountryISO2Code
+	set["_codetype"] = swiftCode.CodeType
+	set["_bankname"] = swiftCode.BankName
+	set["_address"] = swiftCode.Address
+	set["_postaladdress"] = ParseAddress(swiftCode.Address, swiftCode.TownName, swiftCode.CountryISO2Code)
+	set["_townname"] = swiftCode.TownName
+	setSearchKeys(set, SearchKey(swiftCode.BankName), SearchKey(swiftCode.TownName))
+	set["_countryname"] = countryName
+	set["_timezone"] = timeZone
+	location, err := findLocation(ctx, swiftCode.CountryISO2Code, swiftCode.TownName, collectionName)
+	if err != nil {
+		return err
+	}
+	locationUpdate(update, location)
+	_, _, err = changeSwiftCode(ctx, notDeleted(bson.M{"_swiftcode": swiftCodeName}), update, OperationUpdate, collectionName)
+	return err
+}
+
+// versionFilter matches a version, codes stored before versioning have none.
+func versionFilter(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+// changeSwiftCode applies update to the single code matching filter as a new
+// version: the previous state goes to the history and the change is audited.
+// The update is pinned to the version read as before, and undone when the
+// history or the audit can't be written, so no change goes unrecorded.
+func changeSwiftCode(ctx context.Context, filter bson.M, update bson.M, operation string, collectionName string) (SwiftCodes, SwiftCodes, error) {
 	collection := returnCollectionPointer(collectionName)
+	end, err := beginWrite(ctx, collectionName)
+	if err != nil {
+		return SwiftCodes{}, SwiftCodes{}, err
+	}
+	defer end()
 
-	object, err := collection.DeleteOne(context.Background(), bson.M{"_swiftcode": swiftCodeName})
+	var before SwiftCodes
+	err = collection.FindOne(ctx, filter).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return SwiftCodes{}, SwiftCodes{}, fmt.Errorf("swift code with provided name doesn't exist")
+	}
+	if err != nil {
+		log.Println(err)
+		return SwiftCodes{}, SwiftCodes{}, err
+	}
+
+	updatedAt := now()
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+	}
+	if unset, ok := update["$unset"].(bson.M); ok && len(unset) == 0 {
+		delete(update, "$unset")
+	}
+	set["_updatedat"] = updatedAt
+	update["$set"] = set
+	update["$inc"] = bson.M{"_version": 1}
 
-	if object.DeletedCount == 0 {
-		return fmt.Errorf("swift code with provided name doesn't exist")
+	pinned := bson.M{"_version": versionFilter(before.Version)}
+	for key, value := range filter {
+		pinned[key] = value
+	}
+	pinned["_swiftcode"] = before.SwiftCode
+	var after SwiftCodes
+	err = collection.FindOneAndUpdate(ctx, pinned, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&after)
+	if err == mongo.ErrNoDocuments {
+		return SwiftCodes{}, SwiftCodes{}, fmt.Errorf("swift code %s was changed at the same time, try again", before.SwiftCode)
 	}
 	if err != nil {
 		log.Println(err)
-		return err
+		return SwiftCodes{}, SwiftCodes{}, err
 	}
 
-	return nil
+	if err = archiveVersion(ctx, before, updatedAt, operation, collectionName); err != nil {
+		undoChange(ctx, before, after, false, collectionName)
+		return SwiftCodes{}, SwiftCodes{}, err
+	}
+	if err = appendAuditEntry(ctx, operation, before.SwiftCode, &before, &after, collectionName); err != nil {
+		undoChange(ctx, before, after, true, collectionName)
+		return SwiftCodes{}, SwiftCodes{}, err
+	}
+	return before, after, nil
+}
+
+// undoChange puts back the code a failed change started from, together with
+// the history entry archived for it, unless the code changed again since.
+func undoChange(ctx context.Context, before SwiftCodes, after SwiftCodes, archived bool, collectionName string) {
+	collection := returnCollectionPointer(collectionName)
+	_, err := collection.ReplaceOne(ctx, bson.M{"_swiftcode": after.SwiftCode, "_version": after.Version}, before)
+	if err != nil {
+		log.Println("Error while undoing change of", before.SwiftCode, err)
+		return
+	}
+	if !archived {
+		return
+	}
+	history := returnCollectionPointer(historyCollectionName(collectionName))
+	_, err = history.DeleteOne(ctx, bson.M{"_swiftcode": before.SwiftCode, "_version": before.Version, "_supersededat": after.UpdatedAt})
+	if err != nil {
+		log.Println("Error while undoing change of", before.SwiftCode, err)
+	}
+}
+
+// DeleteSwiftCodesWithPrefix deletes a headquarter together with all of its
+// branches and returns the number of deleted codes.
+func (t *SwiftCodes) DeleteSwiftCodesWithPrefix(ctx context.Context, prefix string, reason string, collectionName string) (int64, error) {
+	collection := returnCollectionPointer(collectionName)
+
+	cursor, err := collection.Find(ctx, notDeleted(bson.M{"_swiftcode": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}}))
+	if err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	var swiftCodes []SwiftCodes
+	if err = cursor.All(ctx, &swiftCodes); err != nil {
+		log.Println(err)
+		return 0, err
+	}
+	if len(swiftCodes) == 0 {
+		return 0, fmt.Errorf("swift code with provided name doesn't exist")
+	}
+
+	var deleted int64
+	for _, swiftCode := range swiftCodes {
+		if err = t.DeleteSwiftCode(ctx, swiftCode.SwiftCode, reason, collectionName); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
 }
@@ -0,0 +1,468 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const OperationSync = "sync"
+
+// SyncRemovalReason is recorded on codes a sync deletes.
+const SyncRemovalReason = "removed from directory file"
+
+// SwiftCodeChanges is a code whose directory data differs from the stored
+// one. Fields names the differing fields by their json names.
+type SwiftCodeChanges struct {
+	Before SwiftCodes `json:"before"`
+	After  SwiftCodes `json:"after"`
+	Fields []string   `json:"fields"`
+}
+
+// SyncPlan is the difference between a directory file and the collection.
+// It is computed by PlanSync and only valid for the collection state it was
+// computed from.
+type SyncPlan struct {
+	Additions []SwiftCodes       `json:"additions"`
+	Changes   []SwiftCodeChanges `json:"changes"`
+	Removals  []SwiftCodes       `json:"removals"`
+	Unchanged int                `json:"unchanged"`
+	// Current is the number of codes which weren't deleted when planning.
+	Current int `json:"current"`
+
+	snapshot syncSnapshot
+}
+
+// syncSnapshot identifies the collection state a plan was computed from. It
+// is a hash of the stored documents, so derived data written without a new
+// version, like locations, changes it too.
+type syncSnapshot [sha256.Size]byte
+
+func (p SyncPlan) IsEmpty() bool {
+	return len(p.Additions) == 0 && len(p.Changes) == 0 && len(p.Removals) == 0
+}
+
+// RemovalRatio is the fraction of the current codes the plan removes.
+func (p SyncPlan) RemovalRatio() float64 {
+	if p.Current == 0 {
+		return 0
+	}
+	return float64(len(p.Removals)) / float64(p.Current)
+}
+
+func syncCollectionName(collectionName string) string {
+	return collectionName + "_sync"
+}
+
+// ErrSyncInProgress is returned by the writes refused while a sync is applied.
+var ErrSyncInProgress = errors.New("the directory is being synchronized, try again later")
+
+const (
+	syncLockID = "sync"
+	// A sync or a write holding its lock longer than this crashed and the
+	// lock is ignored.
+	syncLockTimeout  = time.Hour
+	writeLockTimeout = 10 * time.Minute
+	// syncWaitTimeout is how long a sync waits for running writes.
+	syncWaitTimeout = 30 * time.Second
+)
+
+// SyncLocks serialize the writes of a directory with applying a sync. Every
+// write registers a lock of its own and then checks for the lock of a sync,
+// a sync takes its lock and then waits for the registered writes: whichever
+// comes second sees the other.
+type SyncLocks struct {
+	ID    interface{} `bson:"_id"`
+	Since time.Time   `bson:"_since"`
+}
+
+func syncLockCollectionName(collectionName string) string {
+	return collectionName + "_sync_lock"
+}
+
+// beginWrite registers a write of the directory stored in collectionName.
+// It fails with ErrSyncInProgress while a sync is applied, otherwise the
+// returned function ends the write.
+func beginWrite(ctx context.Context, collectionName string) (func(), error) {
+	collection := returnCollectionPointer(syncLockCollectionName(collectionName))
+	id := primitive.NewObjectID()
+	if _, err := collection.InsertOne(ctx, SyncLocks{ID: id, Since: now()}); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	end := func() {
+		if _, err := collection.DeleteOne(context.Background(), bson.M{"_id": id}); err != nil {
+			log.Println(err)
+		}
+	}
+
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": syncLockID, "_since": bson.M{"$gt": now().Add(-syncLockTimeout)}})
+	if err != nil {
+		log.Println(err)
+		end()
+		return nil, err
+	}
+	if count != 0 {
+		end()
+		return nil, ErrSyncInProgress
+	}
+	return end, nil
+}
+
+// beginSync takes the sync lock and waits until the writes registered
+// before it ended. The returned function releases the lock.
+func beginSync(ctx context.Context, collectionName string) (func(), error) {
+	collection := returnCollectionPointer(syncLockCollectionName(collectionName))
+	// A lock left behind by a crashed sync is taken over.
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": syncLockID, "_since": bson.M{"$lte": now().Add(-syncLockTimeout)}})
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if _, err = collection.InsertOne(ctx, SyncLocks{ID: syncLockID, Since: now()}); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrSyncInProgress
+		}
+		log.Println(err)
+		return nil, err
+	}
+	release := func() {
+		if _, err := collection.DeleteOne(context.Background(), bson.M{"_id": syncLockID}); err != nil {
+			log.Println(err)
+		}
+	}
+
+	writes := bson.M{"_id": bson.M{"$ne": syncLockID}, "_since": bson.M{"$gt": now().Add(-writeLockTimeout)}}
+	deadline := time.Now().Add(syncWaitTimeout)
+	for {
+		count, err := collection.CountDocuments(ctx, writes)
+		if err != nil {
+			log.Println(err)
+			release()
+			return nil, err
+		}
+		if count == 0 {
+			return release, nil
+		}
+		if time.Now().After(deadline) {
+			release()
+			return nil, fmt.Errorf("the directory is still being written, try the sync again later")
+		}
+		select {
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// changedFields compares the fields a directory file provides.
+func changedFields(stored SwiftCodes, incoming SwiftCodes) []string {
+	fields := []string{}
+	compare := func(name string, a string, b string) {
+		if a != b {
+			fields = append(fields, name)
+		}
+	}
+	compare("countryiso2code", stored.CountryISO2Code, incoming.CountryISO2Code)
+	compare("codetype", stored.CodeType, incoming.CodeType)
+	compare("bankname", stored.BankName, incoming.BankName)
+	compare("address", stored.Address, incoming.Address)
+	compare("townname", stored.TownName, incoming.TownName)
+	compare("countryname", stored.CountryName, incoming.CountryName)
+	compare("timezone", stored.TimeZone, incoming.TimeZone)
+	if !SameTime(stored.ValidFrom, incoming.ValidFrom) {
+		fields = append(fields, "validfrom")
+	}
+	if !SameTime(stored.ValidTo, incoming.ValidTo) {
+		fields = append(fields, "validto")
+	}
+	if stored.Placeholder != incoming.Placeholder {
+		fields = append(fields, "placeholder")
+	}
+	return fields
+}
+
+// withDirectoryData returns stored with the directory fields of incoming.
+func (s SwiftCodes) withDirectoryData(incoming SwiftCodes) SwiftCodes {
+	s.CountryISO2Code = incoming.CountryISO2Code
+	s.CodeType = incoming.CodeType
+	s.BankName = incoming.BankName
+	s.Address = incoming.Address
+	s.TownName = incoming.TownName
+	s.CountryName = incoming.CountryName
+	s.TimeZone = incoming.TimeZone
+	s.ValidFrom = incoming.ValidFrom
+	s.ValidTo = incoming.ValidTo
+	s.Placeholder = incoming.Placeholder
+	return s.withDerivedFields()
+}
+
+func loadAllSwiftCodes(ctx context.Context, collectionName string) ([]SwiftCodes, syncSnapshot, error) {
+	collection := returnCollectionPointer(collectionName)
+
+	opts := options.Find().SetSort(bson.D{{Key: "_swiftcode", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, syncSnapshot{}, err
+	}
+	var documents []bson.Raw
+	if err = cursor.All(ctx, &documents); err != nil {
+		log.Println(err)
+		return nil, syncSnapshot{}, err
+	}
+
+	swiftCodes := make([]SwiftCodes, len(documents))
+	hash := sha256.New()
+	for i, document := range documents {
+		if err = bson.Unmarshal(document, &swiftCodes[i]); err != nil {
+			log.Println(err)
+			return nil, syncSnapshot{}, err
+		}
+		hash.Write(document)
+	}
+	var snapshot syncSnapshot
+	copy(snapshot[:], hash.Sum(nil))
+	return swiftCodes, snapshot, nil
+}
+
+// PlanSync compares the codes of a directory file with the collection.
+// Codes missing from the file are removals, deleted codes present in it are
+// additions.
+func (t *SwiftCodes) PlanSync(incoming []SwiftCodes, collectionName string) (SyncPlan, error) {
+	plan := SyncPlan{
+		Additions: []SwiftCodes{},
+		Changes:   []SwiftCodeChanges{},
+		Removals:  []SwiftCodes{},
+	}
+
+	stored, snapshot, err := loadAllSwiftCodes(context.Background(), collectionName)
+	if err != nil {
+		return plan, err
+	}
+	plan.snapshot = snapshot
+
+	current := map[string]SwiftCodes{}
+	for _, swiftCode := range stored {
+		if swiftCode.DeletedAt == nil {
+			current[swiftCode.SwiftCode] = swiftCode
+		}
+	}
+	plan.Current = len(current)
+
+	locations, err := loadGazetteer(context.Background(), collectionName)
+	if err != nil {
+		return plan, err
+	}
+
+	seen := map[string]bool{}
+	for _, swiftCode := range incoming {
+		if seen[swiftCode.SwiftCode] {
+			return plan, fmt.Errorf("swift code %s appears more than once in the directory file", swiftCode.SwiftCode)
+		}
+		seen[swiftCode.SwiftCode] = true
+
+		before, ok := current[swiftCode.SwiftCode]
+		if !ok {
+			addition := swiftCode.withDerivedFields()
+			addition.Location = locations[placeKey(addition.CountryISO2Code, addition.TownName)]
+			plan.Additions = append(plan.Additions, addition)
+			continue
+		}
+		fields := changedFields(before, swiftCode)
+		if len(fields) == 0 {
+			plan.Unchanged++
+			continue
+		}
+		after := before.withDirectoryData(swiftCode)
+		after.Location = locations[placeKey(after.CountryISO2Code, after.TownName)]
+		plan.Changes = append(plan.Changes, SwiftCodeChanges{
+			Before: before,
+			After:  after,
+			Fields: fields,
+		})
+	}
+
+	for _, swiftCode := range stored {
+		if swiftCode.DeletedAt == nil && !seen[swiftCode.SwiftCode] {
+			plan.Removals = append(plan.Removals, swiftCode)
+		}
+	}
+	return plan, nil
+}
+
+// ApplySync writes a plan as a whole. Writes of the directory are refused
+// with ErrSyncInProgress while it runs. The new state of the collection is
+// built in a staging collection, the history and audit of the changes are
+// written and the staging collection then replaces the live one by a rename.
+// Readers see either the old or the new directory, but the history and audit
+// are not written atomically with the rename: they may show the sync shortly
+// before the directory does, and are removed again when the rename fails.
+// The sync is refused when the plan would remove more than maxRemovalRatio
+// of the current codes or when the collection changed since the plan was
+// computed.
+func (t *SwiftCodes) ApplySync(ctx context.Context, plan SyncPlan, maxRemovalRatio float64, collectionName string) error {
+	if plan.RemovalRatio() > maxRemovalRatio {
+		return fmt.Errorf("sync would remove %d of %d swift codes (%.1f%%), more than the allowed %.1f%%",
+			len(plan.Removals), plan.Current, plan.RemovalRatio()*100, maxRemovalRatio*100)
+	}
+	if plan.IsEmpty() {
+		return nil
+	}
+
+	release, err := beginSync(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	stored, snapshot, err := loadAllSwiftCodes(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+	if snapshot != plan.snapshot {
+		return fmt.Errorf("swift codes changed since the sync was planned, plan it again")
+	}
+
+	updatedAt := now()
+	changes := map[string]SwiftCodes{}
+	for _, change := range plan.Changes {
+		changes[change.After.SwiftCode] = change.After
+	}
+	additions := map[string]SwiftCodes{}
+	for _, addition := range plan.Additions {
+		additions[addition.SwiftCode] = addition
+	}
+	removals := map[string]bool{}
+	for _, removal := range plan.Removals {
+		removals[removal.SwiftCode] = true
+	}
+
+	// befores holds the replaced state of every code the sync touches,
+	// keyed like afters; codes new to the collection have no before.
+	befores := map[string]SwiftCodes{}
+	afters := []SwiftCodes{}
+	documents := []interface{}{}
+	for _, swiftCode := range stored {
+		document := swiftCode
+		touched := true
+		if after, ok := changes[swiftCode.SwiftCode]; ok {
+			document = after
+		} else if addition, ok := additions[swiftCode.SwiftCode]; ok {
+			// A deleted code comes back.
+			document = addition
+			delete(additions, swiftCode.SwiftCode)
+		} else if removals[swiftCode.SwiftCode] {
+			document.DeletedAt = &updatedAt
+			document.DeleteReason = SyncRemovalReason
+		} else {
+			touched = false
+		}
+
+		if touched {
+			document.Version = swiftCode.Version + 1
+			document.UpdatedAt = updatedAt
+			befores[swiftCode.SwiftCode] = swiftCode
+			afters = append(afters, document)
+		}
+		documents = append(documents, document)
+	}
+	for _, addition := range plan.Additions {
+		if _, ok := additions[addition.SwiftCode]; !ok {
+			continue
+		}
+		addition.Version, err = nextVersion(ctx, addition.SwiftCode, collectionName)
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		addition.UpdatedAt = updatedAt
+		afters = append(afters, addition)
+		documents = append(documents, addition)
+	}
+
+	staging := returnCollectionPointer(syncCollectionName(collectionName))
+	if err = staging.Drop(ctx); err != nil {
+		log.Println(err)
+		return err
+	}
+	if _, err = staging.InsertMany(ctx, documents); err != nil {
+		log.Println(err)
+		return err
+	}
+	// The rename drops the indexes of the directory, the staging collection
+	// brings its own.
+	if err = createIndexes(ctx, staging); err != nil {
+		return err
+	}
+
+	if err = recordSync(ctx, befores, afters, updatedAt, collectionName); err != nil {
+		undoSyncRecords(befores, updatedAt, collectionName)
+		staging.Drop(context.Background())
+		return err
+	}
+
+	db := staging.Database()
+	err = client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "renameCollection", Value: db.Name() + "." + staging.Name()},
+		{Key: "to", Value: db.Name() + "." + collectionName},
+		{Key: "dropTarget", Value: true},
+	}).Err()
+	if err != nil {
+		log.Println(err)
+		undoSyncRecords(befores, updatedAt, collectionName)
+		staging.Drop(context.Background())
+		return err
+	}
+	return nil
+}
+
+// recordSync writes the history and audit of the codes a sync changes.
+func recordSync(ctx context.Context, befores map[string]SwiftCodes, afters []SwiftCodes, updatedAt time.Time, collectionName string) error {
+	for _, after := range afters {
+		after := after
+		before, ok := befores[after.SwiftCode]
+		if !ok {
+			if err := appendAuditEntry(ctx, OperationSync, after.SwiftCode, nil, &after, collectionName); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := archiveVersion(ctx, before, updatedAt, OperationSync, collectionName); err != nil {
+			return err
+		}
+		if err := appendAuditEntry(ctx, OperationSync, after.SwiftCode, &before, &after, collectionName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// undoSyncRecords removes what recordSync wrote for a sync which didn't
+// replace the directory. Syncs don't overlap, so the audit entries of the
+// sync are the ones written since it started.
+func undoSyncRecords(befores map[string]SwiftCodes, updatedAt time.Time, collectionName string) {
+	ctx := context.Background()
+	history := returnCollectionPointer(historyCollectionName(collectionName))
+	for _, before := range befores {
+		filter := bson.M{"_swiftcode": before.SwiftCode, "_version": before.Version, "_supersededat": updatedAt}
+		if _, err := history.DeleteOne(ctx, filter); err != nil {
+			log.Println("Error while removing the history of a failed sync", err)
+		}
+	}
+	audit := returnCollectionPointer(auditCollectionName(collectionName))
+	filter := bson.M{"_operation": OperationSync, "_timestamp": bson.M{"$gte": updatedAt}}
+	if _, err := audit.DeleteMany(ctx, filter); err != nil {
+		log.Println("Error while removing the audit of a failed sync", err)
+	}
+}
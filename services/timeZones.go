@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	// Time zones are checked against the embedded database, so validation
+	// doesn't depend on the zoneinfo files of the host.
+	_ "time/tzdata"
+)
+
+// zones maps countries to their IANA time zones, taken from zone.tab of the
+// time zone database.
+//
+//go:embed zones.csv
+var zones []byte
+
+var zonesByCountry = map[string][]string{}
+
+// BusinessHours are the local hours, Monday to Friday, reported as business
+// time by LocalTimeAt.
+const (
+	BusinessHoursStart = 9
+	BusinessHoursEnd   = 17
+)
+
+// LocalTimes describe the current time at a bank.
+type LocalTimes struct {
+	SwiftCode        string    `json:"swiftcode"`
+	TimeZone         string    `json:"timezone"`
+	LocalTime        time.Time `json:"localtime"`
+	Abbreviation     string    `json:"abbreviation"`
+	UTCOffset        string    `json:"utcoffset"`
+	UTCOffsetSeconds int       `json:"utcoffsetseconds"`
+	BusinessDay      bool      `json:"businessday"`
+	BusinessHours    bool      `json:"businesshours"`
+}
+
+func init() {
+	records, err := csv.NewReader(bytes.NewReader(zones)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("zones.csv: %v", err))
+	}
+	for _, record := range records[1:] {
+		zonesByCountry[record[0]] = append(zonesByCountry[record[0]], record[1])
+	}
+}
+
+// CountryTimeZones lists the time zones of a country.
+func CountryTimeZones(isoCode string) []string {
+	return zonesByCountry[strings.ToUpper(isoCode)]
+}
+
+func loadTimeZone(name string) (*time.Location, error) {
+	// time.LoadLocation also accepts "" and "Local", which depend on the host.
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return time.LoadLocation(name)
+}
+
+// ValidateTimeZone checks a time zone against the IANA time zone database and
+// returns it. An empty time zone defaults to the zone of the country when the
+// country has only one, and stays empty otherwise.
+func ValidateTimeZone(timeZone string, isoCode string) (string, error) {
+	if timeZone == "" {
+		if countryZones := CountryTimeZones(isoCode); len(countryZones) == 1 {
+			return countryZones[0], nil
+		}
+		return "", nil
+	}
+	if _, err := loadTimeZone(timeZone); err != nil {
+		return "", fmt.Errorf("%s is not an IANA time zone", timeZone)
+	}
+	return timeZone, nil
+}
+
+// LocalTimeAt is the time at the bank of swiftCode at the instant at.
+func LocalTimeAt(swiftCode SwiftCodes, at time.Time) (LocalTimes, error) {
+	if swiftCode.TimeZone == "" {
+		return LocalTimes{}, fmt.Errorf("swift code %s has no time zone", swiftCode.SwiftCode)
+	}
+	location, err := loadTimeZone(swiftCode.TimeZone)
+	if err != nil {
+		return LocalTimes{}, fmt.Errorf("swift code %s has an unknown time zone %s", swiftCode.SwiftCode, swiftCode.TimeZone)
+	}
+
+	local := at.In(location)
+	abbreviation, offset := local.Zone()
+	businessDay := local.Weekday() != time.Saturday && local.Weekday() != time.Sunday
+	return LocalTimes{
+		SwiftCode:        swiftCode.SwiftCode,
+		TimeZone:         swiftCode.TimeZone,
+		LocalTime:        local,
+		Abbreviation:     abbreviation,
+		UTCOffset:        local.Format("-07:00"),
+		UTCOffsetSeconds: offset,
+		BusinessDay:      businessDay,
+		BusinessHours:    businessDay && local.Hour() >= BusinessHoursStart && local.Hour() < BusinessHoursEnd,
+	}, nil
+}
+
+// GetLocalTime is the current time at the bank of a code in effect now.
+func (t *SwiftCodes) GetLocalTime(swiftCodeName string, collectionName string) (LocalTimes, error) {
+	swiftCode, err := t.FindSwiftCode(swiftCodeName, LookupOptions{}, collectionName)
+	if err != nil {
+		return LocalTimes{}, err
+	}
+	return LocalTimeAt(swiftCode, time.Now())
+}
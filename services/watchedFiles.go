@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	WatchedImported  = "imported"
+	WatchedFailed    = "failed"
+	WatchedDuplicate = "duplicate"
+)
+
+// WatchedFiles record what happened to files picked up from the watched
+// directory. Checksum identifies the content, so a file dropped again
+// unchanged is not imported twice.
+type WatchedFiles struct {
+	FileName    string    `json:"filename" bson:"_filename"`
+	Checksum    string    `json:"checksum" bson:"_checksum"`
+	Size        int64     `json:"size" bson:"_size"`
+	Status      string    `json:"status" bson:"_status"`
+	JobID       string    `json:"jobid,omitempty" bson:"_jobid,omitempty"`
+	Error       string    `json:"error,omitempty" bson:"_error,omitempty"`
+	MovedTo     string    `json:"movedto" bson:"_movedto"`
+	ProcessedAt time.Time `json:"processedat" bson:"_processedat"`
+}
+
+func (f *WatchedFiles) RecordWatchedFile(watchedFile WatchedFiles, collectionName string) error {
+	collection := returnCollectionPointer(collectionName)
+	watchedFile.ProcessedAt = now()
+	if _, err := collection.InsertOne(context.Background(), watchedFile); err != nil {
+		log.Println(err)
+		return err
+	}
+	return nil
+}
+
+// IsChecksumImported reports whether a file with this content was imported
+// before.
+func (f *WatchedFiles) IsChecksumImported(checksum string, collectionName string) bool {
+	collection := returnCollectionPointer(collectionName)
+	count, err := collection.CountDocuments(context.Background(), bson.M{"_checksum": checksum, "_status": WatchedImported})
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return count != 0
+}
+
+// GetWatchedFiles returns the most recently processed files first.
+func (f *WatchedFiles) GetWatchedFiles(limit int64, collectionName string) ([]WatchedFiles, error) {
+	collection := returnCollectionPointer(collectionName)
+	watchedFiles := []WatchedFiles{}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_processedat", Value: -1}}).SetLimit(limit)
+	cursor, err := collection.Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if err = cursor.All(context.Background(), &watchedFiles); err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	return watchedFiles, nil
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAddress(t *testing.T) {
+	address := services.ParseAddress("HYRJA 3 RR. DRITAN HOXHA ND. 11 TIRANA, TIRANA, 1023", "TIRANA", "AL")
+	assert.Equal(t, services.PostalAddresses{
+		StreetName:         "HYRJA 3 RR. DRITAN HOXHA ND.",
+		BuildingNumber:     "11",
+		PostCode:           "1023",
+		TownName:           "TIRANA",
+		CountrySubDivision: "TIRANA",
+		Country:            "AL",
+	}, *address)
+
+	//Check if app collapses whitespace and upper cases the fields
+	address = services.ParseAddress("ul chlodna 52  Warszawa, mazowieckie, 00-872", "Warszawa", "pl")
+	assert.Equal(t, "UL CHLODNA", address.StreetName)
+	assert.Equal(t, "52", address.BuildingNumber)
+	assert.Equal(t, "00-872", address.PostCode)
+	assert.Equal(t, "WARSZAWA", address.TownName)
+	assert.Equal(t, "MAZOWIECKIE", address.CountrySubDivision)
+	assert.Equal(t, "PL", address.Country)
+
+	//Check if app finds leading building numbers and towns spelled without dots
+	address = services.ParseAddress("3-7 BOULEVARD DES MOULINS  MONACO, MONACO, 98000", "MONACO", "MC")
+	assert.Equal(t, "BOULEVARD DES MOULINS", address.StreetName)
+	assert.Equal(t, "3-7", address.BuildingNumber)
+	address = services.ParseAddress("SREDNA GORA STR 49 FLOOR 6 SOFIA, SOFIA, 1303", "SOFIA", "BG")
+	assert.Equal(t, "", address.BuildingNumber)
+	address = services.ParseAddress("LEVEL 2 WEST, MERCURY TOWER ELIA ZAMMIT STREET - ST. JULIAN'S, ST JULIAN'S, STJ 3155", "ST. JULIAN'S", "MT")
+	assert.Equal(t, "LEVEL 2 WEST, MERCURY TOWER ELIA ZAMMIT STREET", address.StreetName)
+	assert.Equal(t, "STJ 3155", address.PostCode)
+
+	//Check if app handles addresses with only a town and subdivision or nothing at all
+	address = services.ParseAddress("  GDANSK, POMORSKIE", "GDANSK", "PL")
+	assert.Equal(t, "", address.StreetName)
+	assert.Equal(t, "", address.PostCode)
+	assert.Equal(t, "POMORSKIE", address.CountrySubDivision)
+	address = services.ParseAddress("   ", "PUERTO MONTT", "CL")
+	assert.Equal(t, services.PostalAddresses{TownName: "PUERTO MONTT", Country: "CL"}, *address)
+}
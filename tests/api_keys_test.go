@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-mongo-app/auth"
+	"github.com/go-mongo-app/config"
+	"github.com/go-mongo-app/handlers"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const apiKeysCollectionName string = "test_api_keys"
+
+func TestApiKeyLifecycle(t *testing.T) {
+	defer testClient.Database("swift_codes_db").Collection(apiKeysCollectionName).Drop(context.Background())
+
+	var apiKey services.ApiKeys
+	rawKey, err := apiKey.CreateApiKey("importer", auth.ScopeWrite, apiKeysCollectionName)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, rawKey)
+	//Check if app don't create second active key with the same name
+	_, err = apiKey.CreateApiKey("importer", auth.ScopeRead, apiKeysCollectionName)
+	assert.Error(t, err)
+
+	found, err := apiKey.GetApiKeyByKey(rawKey, apiKeysCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "importer", found.Name)
+	assert.Equal(t, auth.ScopeWrite, found.Scope)
+	assert.NotEqual(t, rawKey, found.KeyHash)
+
+	err = apiKey.RevokeApiKey("importer", apiKeysCollectionName)
+	assert.NoError(t, err)
+	_, err = apiKey.GetApiKeyByKey(rawKey, apiKeysCollectionName)
+	assert.Error(t, err)
+	err = apiKey.RevokeApiKey("importer", apiKeysCollectionName)
+	assert.Error(t, err)
+}
+
+func TestApiKeyScopes(t *testing.T) {
+	read := auth.Principal{Roles: []auth.Role{auth.RoleForScope(auth.ScopeRead)}}
+	write := auth.Principal{Roles: []auth.Role{auth.RoleForScope(auth.ScopeWrite)}}
+	assert.True(t, read.HasRole(auth.RoleReader))
+	assert.False(t, read.HasRole(auth.RoleEditor))
+	assert.True(t, write.HasRole(auth.RoleReader))
+	assert.True(t, write.HasRole(auth.RoleEditor))
+	assert.False(t, write.HasRole(auth.RoleAdmin))
+	_, err := auth.ParseScope("admin")
+	assert.Error(t, err)
+}
+
+func TestWriteEndpointsRequireApiKey(t *testing.T) {
+	router := handlers.CreateRouter(config.Config{Auth: config.Auth{Enabled: true}})
+
+	req := httptest.NewRequest("DELETE", "/v1/swift-codes/TESTCODEXXX", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest("GET", "/v1/healthcheck", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
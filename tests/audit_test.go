@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const auditedCollectionName string = "test_audited"
+
+func TestAuditEntriesForMutations(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	defer database.Collection(auditedCollectionName).Drop(context.Background())
+	defer database.Collection(auditedCollectionName + "_audit").Drop(context.Background())
+
+	ctx := services.WithAuditContext(context.Background(), services.AuditContext{Actor: "apikey:tester", RequestID: "req-1"})
+	start := time.Now().Add(-time.Second)
+
+	swiftCode := services.SwiftCodes{
+		SwiftCode:       "AUDITCODXXX",
+		CountryISO2Code: "AQ",
+		BankName:        "TestBank",
+		Address:         "Test address",
+		CountryName:     "ANTARCTICA",
+		IsHeadQuater:    true,
+	}
+	err := swiftCode.InsertSwiftCode(ctx, swiftCode, auditedCollectionName)
+	assert.NoError(t, err)
+	swiftCode.BankName = "Renamed Bank"
+	err = swiftCode.UpdateSwiftCode(ctx, "AUDITCODXXX", swiftCode, auditedCollectionName)
+	assert.NoError(t, err)
+	err = swiftCode.DeleteSwiftCode(context.Background(), "AUDITCODXXX", "", auditedCollectionName)
+	assert.NoError(t, err)
+
+	var auditEntry services.AuditEntries
+	auditEntries, err := auditEntry.GetAuditEntries(services.AuditFilter{SwiftCode: "AUDITCODXXX"}, auditedCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(auditEntries))
+	//Entries are returned newest first
+	assert.Equal(t, services.OperationDelete, auditEntries[0].Operation)
+	assert.Equal(t, "system", auditEntries[0].Actor)
+	assert.Equal(t, "Renamed Bank", auditEntries[0].Before.BankName)
+	assert.NotNil(t, auditEntries[0].After.DeletedAt)
+	assert.Equal(t, services.OperationUpdate, auditEntries[1].Operation)
+	assert.Equal(t, "TestBank", auditEntries[1].Before.BankName)
+	assert.Equal(t, "Renamed Bank", auditEntries[1].After.BankName)
+	assert.Equal(t, services.OperationCreate, auditEntries[2].Operation)
+	assert.Equal(t, "req-1", auditEntries[2].RequestID)
+
+	auditEntries, err = auditEntry.GetAuditEntries(services.AuditFilter{Actor: "apikey:tester", From: start}, auditedCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(auditEntries))
+	auditEntries, err = auditEntry.GetAuditEntries(services.AuditFilter{To: start}, auditedCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(auditEntries))
+}
+
+func TestChangesWithoutAuditAreUndone(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	defer database.Collection(auditedCollectionName).Drop(context.Background())
+	defer database.Collection(auditedCollectionName + "_history").Drop(context.Background())
+	defer database.Collection(auditedCollectionName + "_audit").Drop(context.Background())
+	ctx := context.Background()
+
+	swiftCode := services.SwiftCodes{
+		SwiftCode:       "AUDITFAIXXX",
+		CountryISO2Code: "AQ",
+		BankName:        "TestBank",
+		Address:         "Test address",
+		CountryName:     "ANTARCTICA",
+		IsHeadQuater:    true,
+	}
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, swiftCode, auditedCollectionName))
+
+	//Check if app undoes a change whose audit entry can't be written
+	assert.NoError(t, database.Collection(auditedCollectionName+"_audit").Drop(ctx))
+	assert.NoError(t, database.CreateCollection(ctx, auditedCollectionName+"_audit",
+		options.CreateCollection().SetValidator(bson.M{"_nothing": bson.M{"$exists": true}})))
+	swiftCode.BankName = "Renamed Bank"
+	assert.Error(t, swiftCode.UpdateSwiftCode(ctx, "AUDITFAIXXX", swiftCode, auditedCollectionName))
+
+	stored, err := swiftCode.GetSwiftCodeBySwiftCodeName("AUDITFAIXXX", auditedCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "TestBank", stored.BankName)
+	assert.Equal(t, 1, stored.Version)
+	history, err := swiftCode.GetSwiftCodeHistory("AUDITFAIXXX", auditedCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(history))
+
+	//Check if app doesn't keep a code created without an audit entry
+	swiftCode.SwiftCode = "AUDITNEWXXX"
+	assert.Error(t, swiftCode.InsertSwiftCode(ctx, swiftCode, auditedCollectionName))
+	assert.False(t, swiftCode.IsSwiftCodeInDatabase("AUDITNEWXXX", auditedCollectionName))
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const classificationCollectionName string = "test_classification"
+
+func TestClassifySwiftCode(t *testing.T) {
+	assert.Equal(t, services.ClassificationTest, services.ClassifySwiftCode("BSCHESM0XXX"))
+	assert.Equal(t, services.ClassificationPassive, services.ClassifySwiftCode("DEUTDEF1XXX"))
+	assert.Equal(t, services.ClassificationReverseBilling, services.ClassifySwiftCode("NEDSZAJ2XXX"))
+	assert.Equal(t, services.ClassificationLive, services.ClassifySwiftCode("AAISALTRXXX"))
+
+	//Check if app warns only about test and passive codes
+	assert.NotEmpty(t, services.ClassificationWarning(services.ClassificationTest))
+	assert.NotEmpty(t, services.ClassificationWarning(services.ClassificationPassive))
+	assert.Empty(t, services.ClassificationWarning(services.ClassificationLive))
+
+	classifications, err := services.ParseClassifications("Test, passive")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test", "passive"}, classifications)
+	_, err = services.ParseClassifications("connected")
+	assert.Error(t, err)
+}
+
+func TestFilterByClassification(t *testing.T) {
+	dropTestCollections(t, classificationCollectionName, "_history", "_audit")
+	ctx := context.Background()
+
+	var swiftCode services.SwiftCodes
+	for _, code := range []services.SwiftCodes{
+		{SwiftCode: "AAISALTRXXX", CountryISO2Code: "AL", BankName: "UNITED BANK OF ALBANIA SH.A", IsHeadQuater: true},
+		{SwiftCode: "AAISALT0XXX", CountryISO2Code: "AL", BankName: "UNITED BANK OF ALBANIA SH.A", IsHeadQuater: true},
+		{SwiftCode: "AAISALT1XXX", CountryISO2Code: "AL", BankName: "UNITED BANK OF ALBANIA SH.A", IsHeadQuater: true},
+	} {
+		assert.NoError(t, swiftCode.InsertSwiftCode(ctx, code, classificationCollectionName))
+	}
+
+	//Check if app stores the classification on insert
+	found, err := swiftCode.FindSwiftCode("AAISALT0XXX", services.LookupOptions{}, classificationCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ClassificationTest, found.Classification)
+
+	//Check if app filters codes by classification
+	swiftCodes, err := swiftCode.FindSwiftCodes(services.LookupOptions{Classifications: []string{"test", "passive"}}, classificationCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(swiftCodes))
+	byCountry, err := swiftCode.FindSwiftCodesByISOCode("AL", services.LookupOptions{Classifications: []string{"live"}}, classificationCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(byCountry))
+	assert.Equal(t, "AAISALTRXXX", byCountry[0].SwiftCode)
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCountry(t *testing.T) {
+	country, ok := services.LookupCountry("pl")
+	assert.True(t, ok)
+	assert.Equal(t, "POL", country.Alpha3)
+	assert.Equal(t, "616", country.Numeric)
+
+	//Check if app derives the canonical country name
+	name, err := services.ValidateCountry("PL", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "POLAND", name)
+	name, err = services.ValidateCountry("PL", "Republic of Poland")
+	assert.NoError(t, err)
+	assert.Equal(t, "POLAND", name)
+	name, err = services.ValidateCountry("CI", "COTE D'IVOIRE")
+	assert.NoError(t, err)
+	assert.Equal(t, "CÔTE D'IVOIRE", name)
+
+	//Check if app refuses unknown codes and contradicting names
+	_, err = services.ValidateCountry("ST", "string")
+	assert.ErrorContains(t, err, "SAO TOME AND PRINCIPE")
+	_, err = services.ValidateCountry("XX", "")
+	assert.Error(t, err)
+	_, err = services.ValidateCountry("POL", "POLAND")
+	assert.Error(t, err)
+}
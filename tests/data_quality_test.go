@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataQualityReport(t *testing.T) {
+	swiftCodes := []services.SwiftCodes{
+		{SwiftCode: "AAISALTRXXX", CountryISO2Code: "AL", BankName: "UNITED BANK OF ALBANIA SH.A", Address: "HYRJA 3", TownName: "TIRANA", CountryName: "ALBANIA", TimeZone: "Europe/Tirane", IsHeadQuater: true},
+		{SwiftCode: "AAISALTR123", CountryISO2Code: "AL", BankName: "UNITED BANK OF ALBANIA SH.A", Address: "HYRJA 3", TownName: "TIRANA", CountryName: "ALBANIA", TimeZone: "Europe/Tirane"},
+		{SwiftCode: "ORPHALTR123", CountryISO2Code: "AL", BankName: "ORPHAN  BANK", Address: "hyrja  3 ", TownName: "", CountryName: "ALBANIA", TimeZone: ""},
+		{SwiftCode: "MISNALTRXXX", CountryISO2Code: "AL", BankName: "MISNAMED BANK", Address: "RRUGA 1", TownName: "DURRES", CountryName: "ALBANIJA", TimeZone: "Europe/Nowhere", IsHeadQuater: true},
+		{SwiftCode: "UNKNXXTRXXX", CountryISO2Code: "XX", BankName: "UNKNOWN BANK", Address: "RRUGA 2", TownName: "TIRANA", CountryName: "NOWHERE", TimeZone: "Europe/Tirane", IsHeadQuater: true},
+	}
+
+	report := services.BuildDataQualityReport(swiftCodes, 1)
+	assert.Equal(t, 5, report.TotalCodes)
+
+	checks := map[string]services.DataQualityChecks{}
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+		assert.LessOrEqual(t, len(check.Samples), 1)
+	}
+
+	//Check if app finds a branch without headquarter
+	assert.Equal(t, 1, checks[services.CheckOrphanBranches].Count)
+	assert.Equal(t, "ORPHALTR123", checks[services.CheckOrphanBranches].Samples[0].SwiftCode)
+
+	//Check if app finds country codes and names contradicting ISO 3166
+	assert.Equal(t, 1, checks[services.CheckCountryCodes].Count)
+	assert.Equal(t, "XX", checks[services.CheckCountryCodes].Samples[0].Value)
+	assert.Equal(t, 1, checks[services.CheckCountryNames].Count)
+	assert.Equal(t, "ALBANIJA", checks[services.CheckCountryNames].Samples[0].Value)
+
+	//Check if app finds missing and invalid time zones
+	assert.Equal(t, 1, checks[services.CheckMissingTimeZones].Count)
+	assert.Equal(t, 1, checks[services.CheckInvalidTimeZones].Count)
+	assert.Equal(t, "Europe/Nowhere", checks[services.CheckInvalidTimeZones].Samples[0].Value)
+	assert.Equal(t, 1, checks[services.CheckMissingTownNames].Count)
+
+	//Check if app only counts addresses shared by different institutions
+	assert.Equal(t, 3, checks[services.CheckDuplicateAddresses].Count)
+
+	//Check if app finds doubled and trailing spaces
+	assert.Equal(t, 2, checks[services.CheckWhitespace].Count)
+
+	//Check if app keeps every check when there are no problems
+	report = services.BuildDataQualityReport(swiftCodes[:2], 10)
+	assert.Equal(t, 8, len(report.Checks))
+	for _, check := range report.Checks {
+		assert.Equal(t, 0, check.Count, check.Name)
+		assert.NotNil(t, check.Samples)
+	}
+}
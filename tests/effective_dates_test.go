@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const effectiveCollectionName string = "test_effective"
+
+func TestEffectiveDates(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, suffix := range []string{"", "_history", "_audit"} {
+		defer database.Collection(effectiveCollectionName + suffix).Drop(context.Background())
+	}
+	ctx := context.Background()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	nextWeek := today.AddDate(0, 0, 7)
+	nextYear := today.AddDate(1, 0, 0)
+
+	var swiftCode services.SwiftCodes
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, services.SwiftCodes{
+		SwiftCode:       "EFFENOWWXXX",
+		CountryISO2Code: "AQ",
+		BankName:        "Current Bank",
+		CountryName:     "ANTARCTICA",
+		IsHeadQuater:    true,
+	}, effectiveCollectionName))
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, services.SwiftCodes{
+		SwiftCode:       "EFFENEXTXXX",
+		CountryISO2Code: "AQ",
+		BankName:        "Future Bank",
+		CountryName:     "ANTARCTICA",
+		IsHeadQuater:    true,
+		ValidFrom:       &nextWeek,
+	}, effectiveCollectionName))
+
+	//Check if app rejects a validity ending before it starts
+	err := swiftCode.InsertSwiftCode(ctx, services.SwiftCodes{
+		SwiftCode:       "EFFEBADDXXX",
+		CountryISO2Code: "AQ",
+		BankName:        "Bad Bank",
+		CountryName:     "ANTARCTICA",
+		ValidFrom:       &nextWeek,
+		ValidTo:         &today,
+	}, effectiveCollectionName)
+	assert.Error(t, err)
+
+	//Check if app schedules the removal of an existing code
+	assert.NoError(t, swiftCode.SetSwiftCodeValidity(ctx, "EFFENOWWXXX", nil, &nextYear, services.OperationImport, effectiveCollectionName))
+
+	//Check if app only returns codes in effect
+	_, err = swiftCode.FindSwiftCode("EFFENEXTXXX", services.LookupOptions{}, effectiveCollectionName)
+	assert.Error(t, err)
+	found, err := swiftCode.FindSwiftCode("EFFENEXTXXX", services.LookupOptions{EffectiveAt: nextWeek}, effectiveCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "Future Bank", found.BankName)
+	found, err = swiftCode.FindSwiftCode("EFFENOWWXXX", services.LookupOptions{}, effectiveCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, nextYear, found.ValidTo.UTC())
+	_, err = swiftCode.FindSwiftCode("EFFENOWWXXX", services.LookupOptions{EffectiveAt: nextYear}, effectiveCollectionName)
+	assert.Error(t, err)
+
+	//Check if app lists upcoming changes in order
+	changes, err := swiftCode.GetUpcomingChanges(today, today.AddDate(0, 0, 30), effectiveCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, "EFFENEXTXXX", changes[0].SwiftCode)
+	assert.Equal(t, services.ChangeAddition, changes[0].Change)
+
+	changes, err = swiftCode.GetUpcomingChanges(today, nextYear, effectiveCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, services.ChangeRemoval, changes[1].Change)
+	assert.Equal(t, "EFFENOWWXXX", changes[1].SwiftCode)
+}
+
+func TestParseDate(t *testing.T) {
+	date, err := services.ParseDate("2025-03-01")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), date)
+
+	date, err = services.ParseDate("2025-03-01T10:00:00+02:00")
+	assert.NoError(t, err)
+	assert.True(t, date.Equal(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)))
+
+	_, err = services.ParseDate("01.03.2025")
+	assert.Error(t, err)
+}
+
+func TestImportKeepsValidity(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{
+		effectiveCollectionName + "_jobs", effectiveCollectionName + "_jobs_payloads.files", effectiveCollectionName + "_jobs_payloads.chunks",
+		effectiveCollectionName, effectiveCollectionName + "_history", effectiveCollectionName + "_audit",
+	} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := context.Background()
+	nextYear := time.Now().UTC().Truncate(24*time.Hour).AddDate(1, 0, 0)
+
+	var swiftCode services.SwiftCodes
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, services.SwiftCodes{
+		SwiftCode:       "EFFEKEEPXXX",
+		CountryISO2Code: "AQ",
+		BankName:        "Kept Bank",
+		CountryName:     "ANTARCTICA",
+		IsHeadQuater:    true,
+		ValidTo:         &nextYear,
+	}, effectiveCollectionName))
+
+	runImport := func(file string) {
+		var importJob services.ImportJobs
+		job, err := importJob.CreateImportJob(ctx, "directory.csv", "", strings.NewReader(file), effectiveCollectionName+"_jobs")
+		assert.NoError(t, err)
+		assert.NoError(t, parser.RunImportJob(ctx, job.ID, effectiveCollectionName+"_jobs", effectiveCollectionName))
+	}
+
+	//Check if app keeps the dates of a known code imported from a file without validity columns
+	runImport("SWIFT CODE,COUNTRY ISO2 CODE,NAME,COUNTRY NAME\nEFFEKEEPXXX,AQ,Kept Bank,ANTARCTICA\n")
+	stored, err := swiftCode.GetSwiftCodeBySwiftCodeName("EFFEKEEPXXX", effectiveCollectionName)
+	assert.NoError(t, err)
+	assert.True(t, services.SameTime(&nextYear, stored.ValidTo))
+	assert.Equal(t, 1, stored.Version)
+
+	//Check if app clears the dates a file with validity columns leaves empty
+	runImport("SWIFT CODE,COUNTRY ISO2 CODE,NAME,COUNTRY NAME,VALID TO\nEFFEKEEPXXX,AQ,Kept Bank,ANTARCTICA,\n")
+	stored, err = swiftCode.GetSwiftCodeBySwiftCodeName("EFFEKEEPXXX", effectiveCollectionName)
+	assert.NoError(t, err)
+	assert.Nil(t, stored.ValidTo)
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func readFixture(t *testing.T, name string) (parser.Format, []services.SwiftCodes) {
+	content, err := os.ReadFile("testdata/" + name)
+	assert.NoError(t, err)
+
+	format, rows, err := parser.ReadRows(bytes.NewReader(content), "")
+	assert.NoError(t, err)
+	swiftCodes := []services.SwiftCodes{}
+	for _, row := range rows {
+		swiftCode, err := row.ToSwiftCode()
+		assert.NoError(t, err)
+		swiftCodes = append(swiftCodes, swiftCode)
+	}
+	return format, swiftCodes
+}
+
+func TestDetectFormats(t *testing.T) {
+	for fixture, name := range map[string]string{
+		"directory.csv": "csv",
+		"directory.dat": "fixed-width",
+	} {
+		format, swiftCodes := readFixture(t, fixture)
+		assert.Equal(t, name, format.Name())
+		assert.Equal(t, 3, len(swiftCodes))
+		assert.Equal(t, "AAISALTRXXX", swiftCodes[0].SwiftCode)
+		assert.Equal(t, "AL", swiftCodes[0].CountryISO2Code)
+		assert.Equal(t, "UNITED BANK OF ALBANIA SH.A", swiftCodes[0].BankName)
+		assert.Equal(t, "HYRJA 3 RR. DRITAN HOXHA ND. 11 TIRANA, TIRANA, 1023", swiftCodes[0].Address)
+		assert.Equal(t, "TIRANA", swiftCodes[0].TownName)
+		assert.Equal(t, "ALBANIA", swiftCodes[0].CountryName)
+		assert.True(t, swiftCodes[0].IsHeadQuater)
+		assert.Equal(t, "ALBPPLPWCUS", swiftCodes[2].SwiftCode)
+		assert.False(t, swiftCodes[2].IsHeadQuater)
+		//Check if app keeps doubled spaces of the source data
+		assert.Equal(t, "TSAR ASEN 20  VARNA, VARNA, 9002", swiftCodes[1].Address)
+	}
+
+	_, err := parser.DetectFormat([]byte("just some text"))
+	assert.Error(t, err)
+	_, err = parser.FormatByName("pdf")
+	assert.Error(t, err)
+}
+
+func TestFormatsRoundTrip(t *testing.T) {
+	_, original := readFixture(t, "directory.csv")
+	validTo := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	original[0].ValidTo = &validTo
+
+	for _, name := range []string{"csv"} {
+		format, err := parser.FormatByName(name)
+		assert.NoError(t, err)
+
+		var written bytes.Buffer
+		assert.NoError(t, format.Write(&written, original))
+		detected, rows, err := parser.ReadRows(&written, "")
+		assert.NoError(t, err)
+		assert.Equal(t, name, detected.Name())
+		for i, row := range rows {
+			swiftCode, err := row.ToSwiftCode()
+			assert.NoError(t, err)
+			assert.Equal(t, original[i], swiftCode)
+		}
+	}
+
+	//Check if app round trips the fields the fixed-width layout holds
+	format, err := parser.FormatByName("fixed-width")
+	assert.NoError(t, err)
+	var written bytes.Buffer
+	assert.NoError(t, format.Write(&written, original))
+	_, rows, err := parser.ReadRows(&written, "fixed-width")
+	assert.NoError(t, err)
+	for i, row := range rows {
+		assert.Equal(t, original[i].SwiftCode, row.SwiftCode)
+		assert.Equal(t, original[i].BankName, row.BankName)
+		assert.Equal(t, original[i].Address, row.Address)
+		assert.Equal(t, original[i].TownName, row.TownName)
+		assert.Equal(t, original[i].CountryName, row.CountryName)
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const gazetteerCollectionName string = "test_gazetteer"
+
+func TestReadPlaces(t *testing.T) {
+	in, err := os.Open("testdata/gazetteer.csv")
+	assert.NoError(t, err)
+	defer in.Close()
+	places, err := parser.ReadPlaces(in)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(places))
+	assert.Equal(t, "ŁÓDŹ", places[1].TownName)
+	assert.Equal(t, 19.4560, places[1].Longitude)
+
+	//Check if app refuses coordinates out of range and reports their line
+	_, err = parser.ReadPlaces(strings.NewReader("COUNTRY ISO2 CODE,TOWN NAME,LATITUDE,LONGITUDE\nPL,WARSZAWA,52.2,21.0\nPL,NOWHERE,91,21.0\n"))
+	assert.ErrorContains(t, err, "line 3")
+	assert.ErrorContains(t, err, "latitude")
+	_, err = parser.ReadPlaces(strings.NewReader("COUNTRY ISO2 CODE,TOWN NAME,LATITUDE\nPL,WARSZAWA,52.2\n"))
+	assert.ErrorContains(t, err, "LONGITUDE")
+}
+
+func TestFindSwiftCodesNear(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{gazetteerCollectionName, gazetteerCollectionName + "_history", gazetteerCollectionName + "_audit", gazetteerCollectionName + "_gazetteer"} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := context.Background()
+
+	var swiftCode services.SwiftCodes
+	assert.NoError(t, swiftCode.EnsureIndexes(ctx, gazetteerCollectionName))
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, services.SwiftCodes{SwiftCode: "BREXPLPWXXX", CountryISO2Code: "PL", BankName: "MBANK S.A.", TownName: "WARSZAWA", IsHeadQuater: true}, gazetteerCollectionName))
+
+	in, err := os.Open("testdata/gazetteer.csv")
+	assert.NoError(t, err)
+	defer in.Close()
+	places, err := parser.ReadPlaces(in)
+	assert.NoError(t, err)
+	imported, err := swiftCode.ImportPlaces(ctx, places, gazetteerCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, imported)
+
+	//Check if app locates codes stored before the gazetteer and codes inserted after it
+	stored, err := swiftCode.FindSwiftCode("BREXPLPWXXX", services.LookupOptions{}, gazetteerCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, [2]float64{21.0122, 52.2297}, stored.Location.Coordinates)
+	for _, code := range []services.SwiftCodes{
+		{SwiftCode: "BREXPLPWLOD", CountryISO2Code: "PL", BankName: "MBANK S.A.", TownName: "LODZ"},
+		{SwiftCode: "BREXPLPWPRU", CountryISO2Code: "PL", BankName: "MBANK S.A.", TownName: "PRUSZKOW"},
+		{SwiftCode: "BREXPLPWXYZ", CountryISO2Code: "PL", BankName: "MBANK S.A.", TownName: "NOWHERE"},
+	} {
+		assert.NoError(t, swiftCode.InsertSwiftCode(ctx, code, gazetteerCollectionName))
+	}
+	stored, err = swiftCode.FindSwiftCode("BREXPLPWLOD", services.LookupOptions{}, gazetteerCollectionName)
+	assert.NoError(t, err)
+	assert.NotNil(t, stored.Location)
+
+	//Check if app finds the codes around a point, nearest first
+	near, err := swiftCode.FindSwiftCodesNear(ctx, 52.23, 21.01, 30, services.LookupOptions{}, gazetteerCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(near))
+	assert.Equal(t, "BREXPLPWXXX", near[0].SwiftCode)
+	assert.Equal(t, "BREXPLPWPRU", near[1].SwiftCode)
+	assert.Less(t, near[0].DistanceKm, 1.0)
+	assert.InDelta(t, 15, near[1].DistanceKm, 2)
+
+	_, err = swiftCode.FindSwiftCodesNear(ctx, 95, 21.01, 30, services.LookupOptions{}, gazetteerCollectionName)
+	assert.Error(t, err)
+
+	//Check if app rekeys places stored under keys of an older version
+	gazetteer := testClient.Database("swift_codes_db").Collection(gazetteerCollectionName + "_gazetteer")
+	_, err = gazetteer.InsertOne(ctx, services.Places{CountryISO2Code: "PL", TownName: "ŁAŃCUT", Key: "PL|ŁANCUT", Latitude: 50.0687, Longitude: 22.2293})
+	assert.NoError(t, err)
+	rekeyed, err := swiftCode.UpdatePlaceKeys(ctx, gazetteerCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rekeyed)
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, services.SwiftCodes{SwiftCode: "BREXPLPWLAN", CountryISO2Code: "PL", BankName: "MBANK S.A.", TownName: "LANCUT"}, gazetteerCollectionName))
+	stored, err = swiftCode.FindSwiftCode("BREXPLPWLAN", services.LookupOptions{}, gazetteerCollectionName)
+	assert.NoError(t, err)
+	assert.NotNil(t, stored.Location)
+	rekeyed, err = swiftCode.UpdatePlaceKeys(ctx, gazetteerCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, rekeyed)
+}
@@ -0,0 +1,18 @@
+package tests
+
+import (
+	"context"
+	"testing"
+)
+
+// dropTestCollections drops the collection name and the collections named
+// after it with suffixes once the test finishes.
+func dropTestCollections(t *testing.T, name string, suffixes ...string) {
+	t.Cleanup(func() {
+		database := testClient.Database("swift_codes_db")
+		database.Collection(name).Drop(context.Background())
+		for _, suffix := range suffixes {
+			database.Collection(name + suffix).Drop(context.Background())
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const historyCollectionName string = "test_history"
+
+func TestSwiftCodeHistory(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, suffix := range []string{"", "_history", "_audit"} {
+		defer database.Collection(historyCollectionName + suffix).Drop(context.Background())
+	}
+	ctx := context.Background()
+	pause := func() time.Time {
+		time.Sleep(10 * time.Millisecond)
+		moment := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		return moment
+	}
+
+	swiftCode := services.SwiftCodes{
+		SwiftCode:       "HISTCODEXXX",
+		CountryISO2Code: "AQ",
+		BankName:        "First Name",
+		CountryName:     "ANTARCTICA",
+		IsHeadQuater:    true,
+	}
+	beforeInsert := pause()
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, swiftCode, historyCollectionName))
+	afterInsert := pause()
+	swiftCode.BankName = "Second Name"
+	assert.NoError(t, swiftCode.UpdateSwiftCode(ctx, "HISTCODEXXX", swiftCode, historyCollectionName))
+	afterUpdate := pause()
+
+	current, err := swiftCode.GetSwiftCodeBySwiftCodeName("HISTCODEXXX", historyCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, current.Version)
+
+	found, err := swiftCode.FindSwiftCode("HISTCODEXXX", services.LookupOptions{AsOf: afterInsert}, historyCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "First Name", found.BankName)
+	found, err = swiftCode.FindSwiftCode("HISTCODEXXX", services.LookupOptions{AsOf: afterUpdate}, historyCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "Second Name", found.BankName)
+	_, err = swiftCode.FindSwiftCode("HISTCODEXXX", services.LookupOptions{AsOf: beforeInsert}, historyCollectionName)
+	assert.Error(t, err)
+
+	assert.NoError(t, swiftCode.DeleteSwiftCode(ctx, "HISTCODEXXX", "", historyCollectionName))
+	afterDelete := pause()
+	_, err = swiftCode.FindSwiftCode("HISTCODEXXX", services.LookupOptions{AsOf: afterDelete}, historyCollectionName)
+	assert.Error(t, err)
+	found, err = swiftCode.FindSwiftCode("HISTCODEXXX", services.LookupOptions{AsOf: afterUpdate}, historyCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "Second Name", found.BankName)
+
+	versions, err := swiftCode.GetSwiftCodeHistory("HISTCODEXXX", historyCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(versions))
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, services.OperationUpdate, versions[0].EndedBy)
+	assert.Equal(t, 2, versions[1].Version)
+	assert.Equal(t, services.OperationDelete, versions[1].EndedBy)
+	assert.NotNil(t, versions[2].Document.DeletedAt)
+	assert.Nil(t, versions[2].SupersededAt)
+
+	//Recreated code continues the version numbering
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, swiftCode, historyCollectionName))
+	current, err = swiftCode.GetSwiftCodeBySwiftCodeName("HISTCODEXXX", historyCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, current.Version)
+}
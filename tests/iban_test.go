@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const bankCodesCollectionName string = "test_bank_codes"
+const ibanCollectionName string = "test_iban"
+
+func TestParseIban(t *testing.T) {
+	iban, err := services.ParseIban("pl61 1090 1014 0000 0712 1981 2874")
+	assert.NoError(t, err)
+	assert.Equal(t, "PL61109010140000071219812874", iban.Electronic)
+	assert.Equal(t, "PL61 1090 1014 0000 0712 1981 2874", iban.Formatted)
+	assert.Equal(t, "PL", iban.CountryISO2Code)
+	assert.Equal(t, "61", iban.CheckDigits)
+	assert.Equal(t, "10901014", iban.BankCode)
+
+	iban, err = services.ParseIban("GB29NWBK60161331926819")
+	assert.NoError(t, err)
+	assert.Equal(t, "NWBK", iban.BankCode)
+	assert.Equal(t, "601613", iban.BranchCode)
+
+	iban, err = services.ParseIban("IT60X0542811101000000123456")
+	assert.NoError(t, err)
+	assert.Equal(t, "05428", iban.BankCode)
+	assert.Equal(t, "11101", iban.BranchCode)
+
+	//Check if app knows the countries added to the registry lately
+	iban, err = services.ParseIban("BY13NBRB3600900000002Z00AB00")
+	assert.NoError(t, err)
+	assert.Equal(t, "NBRB", iban.BankCode)
+	iban, err = services.ParseIban("RU0304452522540817810538091310419")
+	assert.NoError(t, err)
+	assert.Equal(t, "044525225", iban.BankCode)
+	for _, valid := range []string{"OM810180000001299123456", "YE15CBYE0001018861234567891234", "LY83002048000020100120361",
+		"MN121234123456789123", "NI45BAPR00000013000003558124", "SD2129010501234001", "SO211000001001000100141",
+		"BI4210000100010000332045181", "DJ2100010000000154000100186", "FK88SC123456789012"} {
+		_, err = services.ParseIban(valid)
+		assert.NoError(t, err, valid)
+	}
+
+	//Check if app refuses wrong check digits, lengths, structures and countries
+	_, err = services.ParseIban("PL62109010140000071219812874")
+	assert.ErrorContains(t, err, "check digits")
+	_, err = services.ParseIban("PL6110901014000007121981287")
+	assert.ErrorContains(t, err, "28")
+	_, err = services.ParseIban("GB29NWBK6016133192681X")
+	assert.ErrorContains(t, err, "format")
+	_, err = services.ParseIban("US12345678901234567890")
+	assert.Error(t, err)
+}
+
+func TestReadBankCodes(t *testing.T) {
+	in, err := os.Open("testdata/bank_codes.csv")
+	assert.NoError(t, err)
+	defer in.Close()
+	bankCodes, err := parser.ReadBankCodes(in)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(bankCodes))
+	assert.Equal(t, "COBADEFFXXX", bankCodes[1].SwiftCode)
+	assert.Equal(t, "pl-bank-number", bankCodes[0].Scheme)
+	assert.Equal(t, "bank-code", bankCodes[2].Scheme)
+	assert.Equal(t, "601613", bankCodes[3].BankCode)
+
+	//Check if app refuses a code that doesn't fit the scheme of its country
+	_, err = parser.ReadBankCodes(strings.NewReader("COUNTRY ISO2 CODE,BANK CODE,SWIFT CODE,SCHEME\nPL,10901015,WBKPPLPPXXX,\n"))
+	assert.ErrorContains(t, err, "line 2")
+	assert.ErrorContains(t, err, "check digit")
+
+	//Check if app reads a table without schemes as bank codes found in IBANs
+	bankCodes, err = parser.ReadBankCodes(strings.NewReader("COUNTRY ISO2 CODE,BANK CODE,SWIFT CODE\nGB,NWBK,NWBKGB2L\nPL,10901015,WBKPPLPPXXX\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(bankCodes))
+	assert.Equal(t, "bank-code", bankCodes[0].Scheme)
+	assert.Equal(t, "NWBK", bankCodes[0].BankCode)
+	assert.Equal(t, "NWBKGB2LXXX", bankCodes[0].SwiftCode)
+
+	//Check if app reports the line of an invalid row
+	_, err = parser.ReadBankCodes(strings.NewReader("BANK CODE,SWIFT CODE,COUNTRY ISO2 CODE\n10901014,WBKPPLPPXXX,PL\n1,SHORT,PL\n"))
+	assert.ErrorContains(t, err, "line 3")
+	_, err = parser.ReadBankCodes(strings.NewReader("BANK CODE,SWIFT CODE\n10901014,WBKPPLPPXXX\n"))
+	assert.ErrorContains(t, err, "COUNTRY ISO2 CODE")
+}
+
+func TestValidateBankCode(t *testing.T) {
+	code, err := services.ValidateBankCode("sort-code", "GB", "60-16-13")
+	assert.NoError(t, err)
+	assert.Equal(t, "601613", code)
+	code, err = services.ValidateBankCode("aba", "", "021000021")
+	assert.NoError(t, err)
+	assert.Equal(t, "021000021", code)
+	_, err = services.ValidateBankCode("blz", "DE", "37040044")
+	assert.NoError(t, err)
+	_, err = services.ValidateBankCode("pl-bank-number", "PL", "1090 1014")
+	assert.NoError(t, err)
+	_, err = services.ValidateBankCode("ifsc", "IN", "sbin0000300")
+	assert.NoError(t, err)
+
+	//Check if app refuses wrong check digits, formats, countries and schemes
+	_, err = services.ValidateBankCode("aba", "US", "021000022")
+	assert.ErrorContains(t, err, "check digit")
+	_, err = services.ValidateBankCode("aba", "US", "991000021")
+	assert.ErrorContains(t, err, "not a valid aba")
+	_, err = services.ValidateBankCode("pl-bank-number", "PL", "10901015")
+	assert.ErrorContains(t, err, "check digit")
+	_, err = services.ValidateBankCode("blz", "DE", "0370400")
+	assert.Error(t, err)
+	_, err = services.ValidateBankCode("sort-code", "IE", "601613")
+	assert.ErrorContains(t, err, "GB")
+	_, err = services.ValidateBankCode("routing", "US", "021000021")
+	assert.ErrorContains(t, err, "unknown bank code scheme")
+	_, err = services.ValidateBankCode("bank-code", "", "05428")
+	assert.ErrorContains(t, err, "country")
+}
+
+func TestResolveIban(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{bankCodesCollectionName, ibanCollectionName, ibanCollectionName + "_history", ibanCollectionName + "_audit"} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := context.Background()
+
+	var swiftCode services.SwiftCodes
+	for _, code := range []services.SwiftCodes{
+		{SwiftCode: "WBKPPLPPXXX", CountryISO2Code: "PL", BankName: "SANTANDER BANK POLSKA", IsHeadQuater: true},
+		{SwiftCode: "NWBKGB2LXXX", CountryISO2Code: "GB", BankName: "NATIONAL WESTMINSTER BANK", TimeZone: "Europe/London", IsHeadQuater: true},
+		{SwiftCode: "NWBKGB2L123", CountryISO2Code: "GB", BankName: "NATIONAL WESTMINSTER BANK", TimeZone: "Europe/London"},
+	} {
+		assert.NoError(t, swiftCode.InsertSwiftCode(ctx, code, ibanCollectionName))
+	}
+
+	var bankCode services.BankCodes
+	imported, err := bankCode.ImportBankCodes(ctx, []services.BankCodes{{CountryISO2Code: "PL", BankCode: "10901014", SwiftCode: "WBKPPLPPXXX"}}, bankCodesCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, imported)
+
+	//Check if app resolves an IBAN through the bank code table
+	iban, _ := services.ParseIban("PL61109010140000071219812874")
+	resolution, err := swiftCode.ResolveIban(iban, bankCodesCollectionName, ibanCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ResolvedByBankCode, resolution.ResolvedBy)
+	assert.Equal(t, "WBKPPLPPXXX", resolution.Bank.SwiftCode)
+
+	//Check if app falls back to the headquarter with the bank code as BIC prefix
+	iban, _ = services.ParseIban("GB29NWBK60161331926819")
+	resolution, err = swiftCode.ResolveIban(iban, bankCodesCollectionName, ibanCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ResolvedByBicPrefix, resolution.ResolvedBy)
+	assert.Equal(t, "NWBKGB2LXXX", resolution.Bank.SwiftCode)
+
+	//Check if app answers without a bank when it can't resolve the IBAN
+	iban, _ = services.ParseIban("DE89370400440532013000")
+	resolution, err = swiftCode.ResolveIban(iban, bankCodesCollectionName, ibanCollectionName)
+	assert.NoError(t, err)
+	assert.Nil(t, resolution.Bank)
+}
+
+func TestBankCodeCrossReference(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{bankCodesCollectionName, ibanCollectionName, ibanCollectionName + "_history", ibanCollectionName + "_audit"} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := context.Background()
+
+	var swiftCode services.SwiftCodes
+	for _, code := range []services.SwiftCodes{
+		{SwiftCode: "NWBKGB2LXXX", CountryISO2Code: "GB", BankName: "NATIONAL WESTMINSTER BANK", TimeZone: "Europe/London", IsHeadQuater: true},
+		{SwiftCode: "NWBKGB2L123", CountryISO2Code: "GB", BankName: "NATIONAL WESTMINSTER BANK", TimeZone: "Europe/London"},
+	} {
+		assert.NoError(t, swiftCode.InsertSwiftCode(ctx, code, ibanCollectionName))
+	}
+
+	var bankCode services.BankCodes
+	_, err := bankCode.ImportBankCodes(ctx, []services.BankCodes{
+		{CountryISO2Code: "GB", BankCode: "601613", SwiftCode: "NWBKGB2L123"},
+		{CountryISO2Code: "GB", BankCode: "400515", SwiftCode: "NWBKGB2L123"},
+	}, bankCodesCollectionName)
+	assert.NoError(t, err)
+
+	//Check if app finds the bank of a national code
+	resolution, err := swiftCode.ResolveBankCode("sort-code", "", "60-16-13", bankCodesCollectionName, ibanCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "sort-code", resolution.Scheme)
+	assert.Equal(t, "NWBKGB2L123", resolution.Bank.SwiftCode)
+
+	//Check if app lists the national codes of a swift code
+	bankCodes, err := bankCode.GetBankCodesOfSwiftCode("NWBKGB2L123", bankCodesCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(bankCodes))
+	assert.Equal(t, "400515", bankCodes[0].BankCode)
+
+	//Check if app resolves a UK IBAN through its sort code
+	iban, _ := services.ParseIban("GB29NWBK60161331926819")
+	ibanResolution, err := swiftCode.ResolveIban(iban, bankCodesCollectionName, ibanCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ResolvedByBankCode, ibanResolution.ResolvedBy)
+	assert.Equal(t, "NWBKGB2L123", ibanResolution.Bank.SwiftCode)
+}
+
+func TestBankCodesWithoutScheme(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{bankCodesCollectionName, ibanCollectionName, ibanCollectionName + "_history", ibanCollectionName + "_audit"} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := context.Background()
+
+	var swiftCode services.SwiftCodes
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, services.SwiftCodes{
+		SwiftCode: "COBADEFFXXX", CountryISO2Code: "DE", BankName: "COMMERZBANK AG", IsHeadQuater: true,
+	}, ibanCollectionName))
+
+	// A code stored before schemes existed.
+	_, err := database.Collection(bankCodesCollectionName).InsertOne(ctx, bson.M{
+		"_countryiso2code": "DE", "_bankcode": "37040044", "_swiftcode": "COBADEFFXXX",
+	})
+	assert.NoError(t, err)
+
+	bankCodes, err := parser.ReadBankCodes(strings.NewReader("COUNTRY ISO2 CODE,BANK CODE,SWIFT CODE\nDE,37040044,COBADEFF\n"))
+	assert.NoError(t, err)
+	var bankCode services.BankCodes
+	_, err = bankCode.ImportBankCodes(ctx, bankCodes, bankCodesCollectionName)
+	assert.NoError(t, err)
+
+	//Check if app finds a code of a table without SCHEME by the scheme of its country
+	resolution, err := swiftCode.ResolveBankCode("blz", "DE", "37040044", bankCodesCollectionName, ibanCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "blz", resolution.Scheme)
+	assert.Equal(t, "COBADEFFXXX", resolution.Bank.SwiftCode)
+
+	stored, err := bankCode.GetBankCodesOfSwiftCode("COBADEFFXXX", bankCodesCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(stored))
+	assert.Equal(t, services.SchemeBankCode, stored[0].Scheme)
+}
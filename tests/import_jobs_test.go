@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const importJobsCollectionName string = "test_import_jobs"
+const importedCollectionName string = "test_imported"
+
+func TestImportJob(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{
+		importJobsCollectionName, importJobsCollectionName + "_payloads.files", importJobsCollectionName + "_payloads.chunks",
+		importedCollectionName, importedCollectionName + "_history", importedCollectionName + "_audit",
+	} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := services.WithAuditContext(context.Background(), services.AuditContext{Actor: "apikey:importer"})
+
+	file := "COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE\n" +
+		"AQ,IMPOJOBBXXX,BIC11,JOB BANK,STREET 1,TOWN,ANTARCTICA,Europe/Warsaw\n" +
+		"AQ,IMPOJOBB123,BIC11,JOB BANK,STREET 2,TOWN,ANTARCTICA,Europe/Warsaw\n" +
+		"AQ,SHORT,BIC11,BAD BANK,STREET 3,TOWN,ANTARCTICA,Europe/Warsaw\n" +
+		"AQ,IMPOJOBBXXX,BIC11,JOB BANK,STREET 1,TOWN,ANTARCTICA,Europe/Warsaw\n"
+
+	var importJob services.ImportJobs
+	job, err := importJob.CreateImportJob(ctx, "directory.csv", "", strings.NewReader(file), importJobsCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ImportQueued, job.Status)
+	assert.Equal(t, "apikey:importer", job.Actor)
+	assert.Equal(t, int64(len(file)), job.Size)
+
+	assert.NoError(t, parser.RunImportJob(context.Background(), job.ID, importJobsCollectionName, importedCollectionName))
+
+	job, err = importJob.GetImportJob(job.ID, importJobsCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ImportSucceeded, job.Status)
+	assert.Equal(t, "csv", job.Format)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, 4, job.TotalRows)
+	assert.Equal(t, 4, job.ProcessedRows)
+	assert.Equal(t, 2, job.ImportedRows)
+	assert.Equal(t, 1, job.SkippedRows)
+	assert.Equal(t, 1, job.RejectedCount)
+	assert.Equal(t, 4, job.RejectedRows[0].Line)
+	assert.Equal(t, "SHORT", job.RejectedRows[0].SwiftCode)
+
+	//Check if app attributes imported codes to the job creator
+	var audit services.AuditEntries
+	entries, err := audit.GetAuditEntries(services.AuditFilter{SwiftCode: "IMPOJOBBXXX"}, importedCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "apikey:importer", entries[0].Actor)
+
+	//Check if app drops the payload of a finished job
+	_, err = importJob.ReadImportPayload(job.ID, importJobsCollectionName)
+	assert.Error(t, err)
+
+	//Check if app cancels a queued job and refuses to cancel a finished one
+	queued, err := importJob.CreateImportJob(ctx, "directory.csv", "", strings.NewReader(file), importJobsCollectionName)
+	assert.NoError(t, err)
+	queued, err = importJob.CancelImportJob(queued.ID, importJobsCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, services.ImportCancelled, queued.Status)
+	assert.Error(t, parser.RunImportJob(context.Background(), queued.ID, importJobsCollectionName, importedCollectionName))
+	_, err = importJob.CancelImportJob(job.ID, importJobsCollectionName)
+	assert.Error(t, err)
+
+	unfinished, err := importJob.GetUnfinishedImportJobs(importJobsCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(unfinished))
+}
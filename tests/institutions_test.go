@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const institutionsCollectionName string = "test_institutions"
+
+func TestBuildInstitutions(t *testing.T) {
+	institutions := services.BuildInstitutions([]services.SwiftCodes{
+		{SwiftCode: "CITIUS33XXX", CountryISO2Code: "US", BankName: "CITIBANK N.A.", IsHeadQuater: true},
+		{SwiftCode: "CITIPLPXKCM", CountryISO2Code: "PL", BankName: "BANK HANDLOWY W WARSZAWIE S.A."},
+		{SwiftCode: "CITIPLPXXXX", CountryISO2Code: "PL", BankName: "BANK HANDLOWY W WARSZAWIE S.A.", IsHeadQuater: true},
+		{SwiftCode: "CITIGB2LLON", CountryISO2Code: "GB", BankName: "CITIBANK N.A."},
+		{SwiftCode: "AAISALTRXXX", CountryISO2Code: "AL", BankName: "UNITED BANK OF ALBANIA SH.A", IsHeadQuater: true},
+	})
+
+	//Check if app groups BIC8s of one institution across countries
+	assert.Equal(t, 2, len(institutions))
+	assert.Equal(t, "AAIS", institutions[0].InstitutionCode)
+	citi := institutions[1]
+	assert.Equal(t, []string{"GB", "PL", "US"}, citi.Countries)
+	assert.Equal(t, []string{"BANK HANDLOWY W WARSZAWIE S.A.", "CITIBANK N.A."}, citi.BankNames)
+	assert.Equal(t, 2, citi.HeadquarterCount)
+	assert.Equal(t, 2, citi.BranchCount)
+	assert.Equal(t, 3, len(citi.Headquarters))
+
+	//Check if app keeps a BIC8 without headquarter in the tree
+	assert.Equal(t, "CITIGB2L", citi.Headquarters[0].Bic8)
+	assert.Nil(t, citi.Headquarters[0].Headquarter)
+	assert.Equal(t, "CITIGB2LLON", citi.Headquarters[0].Branches[0].SwiftCode)
+	assert.Equal(t, "CITIPLPXXXX", citi.Headquarters[1].Headquarter.SwiftCode)
+	assert.Equal(t, "CITIPLPXKCM", citi.Headquarters[1].Branches[0].SwiftCode)
+
+	assert.Error(t, services.ValidateInstitutionCode("CIT1"))
+}
+
+func TestFindInstitution(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{institutionsCollectionName, institutionsCollectionName + "_history", institutionsCollectionName + "_audit"} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := context.Background()
+
+	var swiftCode services.SwiftCodes
+	for _, code := range []services.SwiftCodes{
+		{SwiftCode: "CITIPLPXXXX", CountryISO2Code: "PL", BankName: "BANK HANDLOWY W WARSZAWIE S.A.", IsHeadQuater: true},
+		{SwiftCode: "CITIPLPXKCM", CountryISO2Code: "PL", BankName: "BANK HANDLOWY W WARSZAWIE S.A."},
+		{SwiftCode: "CITIUS33XXX", CountryISO2Code: "US", BankName: "CITIBANK N.A.", TimeZone: "America/New_York", IsHeadQuater: true},
+		{SwiftCode: "AAISALTRXXX", CountryISO2Code: "AL", BankName: "UNITED BANK OF ALBANIA SH.A", IsHeadQuater: true},
+	} {
+		assert.NoError(t, swiftCode.InsertSwiftCode(ctx, code, institutionsCollectionName))
+	}
+
+	//Check if app returns the tree of an institution
+	institution, err := swiftCode.FindInstitution("CITI", services.LookupOptions{}, institutionsCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PL", "US"}, institution.Countries)
+	assert.Equal(t, 2, len(institution.Headquarters))
+	assert.Equal(t, 1, institution.BranchCount)
+
+	//Check if app lists institutions without their trees
+	institutions, err := swiftCode.FindInstitutions(services.LookupOptions{}, institutionsCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(institutions))
+	assert.Nil(t, institutions[1].Headquarters)
+
+	_, err = swiftCode.FindInstitution("NONE", services.LookupOptions{}, institutionsCollectionName)
+	assert.Error(t, err)
+}
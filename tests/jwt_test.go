@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/auth"
+	"github.com/go-mongo-app/config"
+	"github.com/go-mongo-app/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestJWKS generates an RSA key, stores its public part as a JWKS file
+// and returns the private key together with the file path.
+func writeTestJWKS(t *testing.T, kid string) (*rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return key, path
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTVerification(t *testing.T) {
+	key, path := writeTestJWKS(t, "test-key")
+	verifier := auth.Verifier{
+		Keys:     auth.NewJWKS(path, time.Hour),
+		Issuer:   "https://issuer.test",
+		Audience: "swift-directory",
+		Roles: auth.RoleMapper{
+			Claim:   "groups",
+			Mapping: map[string]auth.Role{"directory-admins": auth.RoleAdmin},
+		},
+	}
+	claims := map[string]any{
+		"sub":    "alice",
+		"iss":    "https://issuer.test",
+		"aud":    []string{"swift-directory"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"groups": []string{"directory-admins", "editor", "unrelated"},
+	}
+
+	principal, err := verifier.Principal(signTestToken(t, key, "test-key", claims))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", principal.Name)
+	assert.Equal(t, []auth.Role{auth.RoleAdmin, auth.RoleEditor}, principal.Roles)
+	//Check if app rejects expired tokens
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = verifier.Principal(signTestToken(t, key, "test-key", claims))
+	assert.Error(t, err)
+	//Check if app rejects tokens for other audience
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	claims["aud"] = "other-service"
+	_, err = verifier.Principal(signTestToken(t, key, "test-key", claims))
+	assert.Error(t, err)
+	//Check if app rejects tokens signed by unknown key
+	otherKey, _ := writeTestJWKS(t, "test-key")
+	claims["aud"] = "swift-directory"
+	_, err = verifier.Principal(signTestToken(t, otherKey, "test-key", claims))
+	assert.Error(t, err)
+}
+
+func TestRoutePermissionsWithJWT(t *testing.T) {
+	key, path := writeTestJWKS(t, "test-key")
+	router := handlers.CreateRouter(config.Config{Auth: config.Auth{
+		Enabled: true,
+		JWT:     config.JWT{JWKS: path, RolesClaim: "roles"},
+	}})
+	token := func(role string) string {
+		return "Bearer " + signTestToken(t, key, "test-key", map[string]any{
+			"sub":   role + "-user",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"roles": []string{role},
+		})
+	}
+
+	req := httptest.NewRequest("DELETE", "/v1/swift-codes/TESTCODEXXX", nil)
+	req.Header.Set("Authorization", token("reader"))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest("DELETE", "/v1/swift-codes/TESTCODEXXX?cascade=true", nil)
+	req.Header.Set("Authorization", token("editor"))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest("PUT", "/v1/swift-codes/TESTCODEXXX", nil)
+	req.Header.Set("Authorization", "Bearer not.a.token")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const orphansCollectionName string = "test_orphans"
+const orphanJobsCollectionName string = "test_orphan_jobs"
+
+func runOrphanImport(t *testing.T, policy services.OrphanPolicies, file string) services.ImportJobs {
+	defer func(previous services.OrphanPolicies) { parser.OrphanPolicy = previous }(parser.OrphanPolicy)
+	parser.OrphanPolicy = policy
+
+	var importJob services.ImportJobs
+	job, err := importJob.CreateImportJob(context.Background(), "orphans.csv", "", strings.NewReader(file), orphanJobsCollectionName)
+	assert.NoError(t, err)
+	assert.NoError(t, parser.RunImportJob(context.Background(), job.ID, orphanJobsCollectionName, orphansCollectionName))
+	job, err = importJob.GetImportJob(job.ID, orphanJobsCollectionName)
+	assert.NoError(t, err)
+	return job
+}
+
+func TestOrphanBranches(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{
+		orphanJobsCollectionName, orphanJobsCollectionName + "_payloads.files", orphanJobsCollectionName + "_payloads.chunks",
+		orphansCollectionName, orphansCollectionName + "_history", orphansCollectionName + "_audit",
+	} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	header := "COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE\n"
+
+	//Check if app finds a headquarter later in the same file
+	job := runOrphanImport(t, services.OrphanReject, header+
+		"PL,ORPHFILE123,BIC11,FILE BANK,STREET 2,WARSZAWA,POLAND,Europe/Warsaw\n"+
+		"PL,ORPHFILEXXX,BIC11,FILE BANK,STREET 1,WARSZAWA,POLAND,Europe/Warsaw\n")
+	assert.Equal(t, 2, job.ImportedRows)
+	assert.Equal(t, 0, job.OrphanCount)
+
+	//Check if app rejects an orphan with the reject policy
+	job = runOrphanImport(t, services.OrphanReject, header+
+		"PL,ORPHREJE123,BIC11,REJECTED BANK,STREET 2,WARSZAWA,POLAND,Europe/Warsaw\n")
+	assert.Equal(t, 0, job.ImportedRows)
+	assert.Equal(t, 1, job.RejectedCount)
+	assert.Contains(t, job.RejectedRows[0].Reason, "ORPHREJEXXX")
+
+	//Check if app imports and counts an orphan with the flag policy
+	job = runOrphanImport(t, services.OrphanFlag, header+
+		"PL,ORPHFLAG123,BIC11,FLAGGED BANK,STREET 2,WARSZAWA,POLAND,Europe/Warsaw\n")
+	assert.Equal(t, 1, job.ImportedRows)
+	assert.Equal(t, 1, job.OrphanCount)
+
+	//Check if app creates a placeholder with the synthesize policy
+	job = runOrphanImport(t, services.OrphanSynthesize, header+
+		"PL,ORPHSYNT123,BIC11,SYNTHESIZED BANK,STREET 2,KRAKOW,POLAND,Europe/Warsaw\n")
+	assert.Equal(t, 1, job.ImportedRows)
+	assert.Equal(t, 1, job.OrphanCount)
+
+	var swiftCode services.SwiftCodes
+	placeholder, err := swiftCode.GetHeadquater("ORPHSYNT", orphansCollectionName)
+	assert.NoError(t, err)
+	assert.True(t, placeholder.Placeholder)
+	assert.Equal(t, "SYNTHESIZED BANK", placeholder.BankName)
+	assert.Equal(t, "", placeholder.Address)
+
+	report, err := swiftCode.GetOrphanReport(orphansCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(report.Branches))
+	assert.Equal(t, "ORPHFLAG123", report.Branches[0].SwiftCode)
+	assert.Equal(t, 1, len(report.Placeholders))
+
+	//Check if app replaces a placeholder by the real headquarter
+	job = runOrphanImport(t, services.OrphanFlag, header+
+		"PL,ORPHSYNTXXX,BIC11,SYNTHESIZED BANK SA,STREET 1,KRAKOW,POLAND,Europe/Warsaw\n")
+	assert.Equal(t, 1, job.ImportedRows)
+	headquarter, err := swiftCode.GetHeadquater("ORPHSYNT", orphansCollectionName)
+	assert.NoError(t, err)
+	assert.False(t, headquarter.Placeholder)
+	assert.Equal(t, "STREET 1", headquarter.Address)
+	assert.Equal(t, 2, headquarter.Version)
+
+	//Check if app reconciles stored orphans
+	ctx := services.WithAuditContext(context.Background(), services.AuditContext{Actor: "system:reconcile"})
+	_, err = swiftCode.ReconcileOrphanBranches(ctx, services.OrphanFlag, orphansCollectionName)
+	assert.Error(t, err)
+	changed, err := swiftCode.ReconcileOrphanBranches(ctx, services.OrphanSynthesize, orphansCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	report, err = swiftCode.GetOrphanReport(orphansCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(report.Branches))
+	assert.Equal(t, "ORPHFLAGXXX", report.Placeholders[0].SwiftCode)
+
+	assert.NoError(t, swiftCode.DeleteSwiftCode(ctx, "ORPHFLAGXXX", "", orphansCollectionName))
+	changed, err = swiftCode.ReconcileOrphanBranches(ctx, services.OrphanReject, orphansCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	assert.True(t, swiftCode.IsSwiftCodeDeleted("ORPHFLAG123", orphansCollectionName))
+
+	//Check if app keeps a deleted headquarter deleted instead of synthesizing it
+	assert.NoError(t, swiftCode.DeleteSwiftCode(ctx, "ORPHFILEXXX", "", orphansCollectionName))
+	job = runOrphanImport(t, services.OrphanSynthesize, header+
+		"PL,ORPHFILE456,BIC11,FILE BANK,STREET 3,WARSZAWA,POLAND,Europe/Warsaw\n")
+	assert.Equal(t, 1, job.ImportedRows)
+	assert.Equal(t, 1, job.OrphanCount)
+	assert.True(t, swiftCode.IsSwiftCodeDeleted("ORPHFILEXXX", orphansCollectionName))
+	changed, err = swiftCode.ReconcileOrphanBranches(ctx, services.OrphanSynthesize, orphansCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, changed)
+	assert.True(t, swiftCode.IsSwiftCodeDeleted("ORPHFILEXXX", orphansCollectionName))
+
+	//Check if app names a deleted headquarter when rejecting a branch
+	job = runOrphanImport(t, services.OrphanReject, header+
+		"PL,ORPHFILE789,BIC11,FILE BANK,STREET 4,WARSZAWA,POLAND,Europe/Warsaw\n")
+	assert.Equal(t, 0, job.ImportedRows)
+	assert.Contains(t, job.RejectedRows[0].Reason, "ORPHFILEXXX of the branch was deleted")
+}
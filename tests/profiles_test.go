@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVProfiles(t *testing.T) {
+	//Check if app detects a semicolon separated windows-1252 file
+	file := []byte("COUNTRY ISO2 CODE;SWIFT CODE;NAME;ADDRESS;TOWN NAME;COUNTRY NAME\r\n" +
+		"FR;BNPAFRPPXXX;BNP PARIBAS;\"16 BD DES ITALIENS; PARIS\";PARIS;FRANCE\r\n" +
+		"FR;BNPAFRPPNIC;BNP PARIBAS;PLACE MASS\xc9NA;NICE;FRANCE\r\n")
+	format, rows, err := parser.ReadRows(bytes.NewReader(file), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "excel-semicolon", format.Name())
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, "16 BD DES ITALIENS; PARIS", rows[0].Address)
+	assert.Equal(t, "PLACE MASSÉNA", rows[1].Address)
+	assert.Equal(t, 3, rows[1].Line)
+
+	//Check if app drops a byte order mark
+	file = []byte("\xef\xbb\xbfSWIFT CODE,NAME\nBNPAFRPPXXX,BNP PARIBAS\n")
+	format, rows, err = parser.ReadRows(bytes.NewReader(file), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "csv", format.Name())
+	assert.Equal(t, "BNPAFRPPXXX", rows[0].SwiftCode)
+
+	//Check if app reads files without a header by column numbers
+	file = []byte("FR,BNPAFRPPXXX,BIC11,BNP PARIBAS,16 BD DES ITALIENS,PARIS,FRANCE,Europe/Paris\n")
+	_, err = parser.DetectFormat(file)
+	assert.Error(t, err)
+	_, rows, err = parser.ReadRows(bytes.NewReader(file), "headerless")
+	assert.NoError(t, err)
+	assert.Equal(t, "BNPAFRPPXXX", rows[0].SwiftCode)
+	assert.Equal(t, "Europe/Paris", rows[0].TimeZone)
+	assert.Equal(t, 1, rows[0].Line)
+
+	//Check if app joins columns mapped to one field and keeps quotes
+	file = []byte("BIC\tINSTITUTION NAME\tSTREET ADDRESS 1\tSTREET ADDRESS 2\tCITY\n" +
+		"BNPAFRPPXXX\tBNP \"PARIBAS\"\t16 BD DES ITALIENS\tBP 1\tPARIS\n")
+	format, rows, err = parser.ReadRows(bytes.NewReader(file), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "tab-separated", format.Name())
+	assert.Equal(t, "BNP \"PARIBAS\"", rows[0].BankName)
+	assert.Equal(t, "16 BD DES ITALIENS BP 1", rows[0].Address)
+}
+
+func TestCustomCSVProfile(t *testing.T) {
+	profile := parser.CSVProfiles{
+		Name:      "test-export",
+		Delimiter: "|",
+		Quoting:   parser.QuotingLazy,
+		Encoding:  "iso-8859-2",
+		HasHeader: true,
+		Columns: map[string][]string{
+			"swiftcode":       {"Bic"},
+			"bankname":        {"Bank"},
+			"townname":        {"City"},
+			"countryiso2code": {"Country"},
+		},
+	}
+	assert.NoError(t, parser.RegisterProfile(profile))
+
+	file := []byte("Bic|Bank|City|Country\nBPKOPLPWXXX|PKO BP|\xa3\xd3D\xac|PL\n")
+	format, rows, err := parser.ReadRows(bytes.NewReader(file), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "test-export", format.Name())
+	assert.Equal(t, "ŁÓDŹ", rows[0].TownName)
+
+	//Check if app writes a profile it can read back
+	var written bytes.Buffer
+	assert.NoError(t, format.Write(&written, []services.SwiftCodes{{SwiftCode: "BPKOPLPWXXX", BankName: "PKO BP", TownName: "ŁÓDŹ", CountryISO2Code: "PL"}}))
+	assert.True(t, bytes.Contains(written.Bytes(), []byte("\xa3\xd3D\xac")))
+	_, rows, err = parser.ReadRows(&written, "test-export")
+	assert.NoError(t, err)
+	assert.Equal(t, "ŁÓDŹ", rows[0].TownName)
+
+	//Check if app rejects invalid profiles
+	for _, invalid := range []parser.CSVProfiles{
+		{Name: "no-swift-code", Columns: map[string][]string{"bankname": {"Bank"}}},
+		{Name: "bad-field", Columns: map[string][]string{"swiftcode": {"Bic"}, "colour": {"Colour"}}},
+		{Name: "bad-delimiter", Delimiter: "||", Columns: map[string][]string{"swiftcode": {"Bic"}}},
+		{Name: "bad-encoding", Encoding: "klingon", Columns: map[string][]string{"swiftcode": {"Bic"}}},
+		{Name: "bad-column", Columns: map[string][]string{"swiftcode": {"Bic"}}},
+	} {
+		assert.Error(t, parser.RegisterProfile(invalid), invalid.Name)
+	}
+	assert.Contains(t, strings.Join(parser.ProfileNames(), ","), "test-export")
+}
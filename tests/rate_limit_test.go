@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-mongo-app/config"
+	"github.com/go-mongo-app/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitPerClient(t *testing.T) {
+	router := handlers.CreateRouter(config.Config{RateLimit: config.RateLimit{
+		Enabled: true,
+		Default: config.RouteLimit{RequestsPerSecond: 100, Burst: 100},
+		Routes: map[string]config.RouteLimit{
+			"GET /v1/healthcheck": {RequestsPerSecond: 0.5, Burst: 2},
+		},
+	}})
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/v1/healthcheck", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1000").Code)
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1001").Code)
+	rec := request("10.0.0.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	//Other clients have their own bucket
+	assert.Equal(t, http.StatusOK, request("10.0.0.2:1000").Code)
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	router := handlers.CreateRouter(config.Config{RateLimit: config.RateLimit{MaxBodyBytes: 64}})
+
+	body := `{"SwiftCode": "` + strings.Repeat("A", 100) + `"}`
+	req := httptest.NewRequest("POST", "/v1/swift-codes", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	//Bodies without Content-Length are cut off while decoding
+	req = httptest.NewRequest("POST", "/v1/swift-codes", bytes.NewBufferString(body))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
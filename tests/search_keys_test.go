@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const searchCollectionName string = "test_search"
+
+func TestSearchKey(t *testing.T) {
+	//Check if app gives names differing in case, accents and spacing one key
+	assert.Equal(t, "LODZ", services.SearchKey("Łódź"))
+	assert.Equal(t, "LODZ", services.SearchKey("LODZ"))
+	assert.Equal(t, "LODZ", services.SearchKey(" lodz "))
+	assert.Equal(t, "SAO PAULO", services.SearchKey("São  Paulo"))
+	assert.Equal(t, "ST JULIAN S", services.SearchKey("St. Julian's"))
+	assert.Equal(t, "STRASSE", services.SearchKey("Straße"))
+	assert.Equal(t, "KOBENHAVN", services.SearchKey("København"))
+
+	//Check if app transliterates Cyrillic and Greek
+	assert.Equal(t, "MOSKVA", services.SearchKey("Москва"))
+	assert.Equal(t, "SOFIYA", services.SearchKey("София"))
+	assert.Equal(t, "ATHINA", services.SearchKey("Αθήνα"))
+	assert.Equal(t, "THESSALONIKI", services.SearchKey("ΘΕΣΣΑΛΟΝΊΚΗ"))
+}
+
+func TestSearchSwiftCodes(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{
+		searchCollectionName, searchCollectionName + "_history", searchCollectionName + "_audit",
+		searchCollectionName + "_migrations", searchCollectionName + "_sync_lock",
+	} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := context.Background()
+
+	var swiftCode services.SwiftCodes
+	assert.NoError(t, swiftCode.EnsureIndexes(ctx, searchCollectionName))
+	for _, code := range []services.SwiftCodes{
+		{SwiftCode: "BREXPLPWXXX", CountryISO2Code: "PL", BankName: "MBANK S.A.", TownName: "WARSZAWA", IsHeadQuater: true},
+		{SwiftCode: "BREXPLPWLOD", CountryISO2Code: "PL", BankName: "MBANK S.A.", TownName: "Łódź"},
+		{SwiftCode: "BPKOPLPWXXX", CountryISO2Code: "PL", BankName: "PKO BANK POLSKI S.A.", TownName: "WARSZAWA", IsHeadQuater: true},
+		{SwiftCode: "BPKOPLPWLOD", CountryISO2Code: "PL", BankName: "PKO BANK POLSKI S.A.", TownName: "LODZ"},
+	} {
+		assert.NoError(t, swiftCode.InsertSwiftCode(ctx, code, searchCollectionName))
+	}
+
+	//Check if app filters by town and bank name regardless of diacritics
+	inLodz, err := swiftCode.FindSwiftCodes(services.LookupOptions{TownName: "Lodz"}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(inLodz))
+	pko, err := swiftCode.FindSwiftCodesByISOCode("PL", services.LookupOptions{TownName: "łódź", BankName: "pko"}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pko))
+	assert.Equal(t, "BPKOPLPWLOD", pko[0].SwiftCode)
+
+	//Check if app searches bank and town names and swift codes
+	found, err := swiftCode.SearchSwiftCodes("mbank lodz", 50, services.LookupOptions{}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(found))
+	assert.Equal(t, "BREXPLPWLOD", found[0].SwiftCode)
+	found, err = swiftCode.SearchSwiftCodes("bpkopl", 1, services.LookupOptions{}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(found))
+	assert.Equal(t, "BPKOPLPWLOD", found[0].SwiftCode)
+	found, err = swiftCode.SearchSwiftCodes("ŁÓDŹ", 50, services.LookupOptions{}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(found))
+
+	//Check if app fills in the search keys of codes stored before them
+	_, err = testClient.Database("swift_codes_db").Collection(searchCollectionName).InsertOne(ctx, bson.M{
+		"_swiftcode": "PKOPPLPWLOD", "_countryiso2code": "PL", "_bankname": "PKO S.A.", "_townname": "ŁÓDŹ", "_version": 1,
+	})
+	assert.NoError(t, err)
+	found, err = swiftCode.SearchSwiftCodes("lodz", 50, services.LookupOptions{}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(found))
+	changed, err := swiftCode.UpdateDerivedFields(ctx, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	found, err = swiftCode.SearchSwiftCodes("lodz", 50, services.LookupOptions{}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(found))
+	assert.Equal(t, "live", found[2].Classification)
+	changed, err = swiftCode.UpdateDerivedFields(ctx, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, changed)
+
+	//Check if app fills in the search keys at startup only once
+	insertLegacy := func(code string) {
+		_, err := testClient.Database("swift_codes_db").Collection(searchCollectionName).InsertOne(ctx, bson.M{
+			"_swiftcode": code, "_countryiso2code": "PL", "_bankname": "PKO S.A.", "_townname": "ŁÓDŹ", "_version": 1,
+		})
+		assert.NoError(t, err)
+	}
+	insertLegacy("PKOPPLPWLO1")
+	assert.NoError(t, swiftCode.RunMigrations(ctx, searchCollectionName))
+	found, err = swiftCode.SearchSwiftCodes("lodz", 50, services.LookupOptions{}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(found))
+	insertLegacy("PKOPPLPWLO2")
+	assert.NoError(t, swiftCode.RunMigrations(ctx, searchCollectionName))
+	found, err = swiftCode.SearchSwiftCodes("lodz", 50, services.LookupOptions{}, searchCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(found))
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-mongo-app/config"
+	"github.com/go-mongo-app/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfiguredCORS(t *testing.T) {
+	router := handlers.CreateRouter(config.Config{CORS: config.CORS{
+		AllowedOrigins: []string{"https://bank.example"},
+		AllowedMethods: []string{"GET", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization"},
+	}})
+
+	req := httptest.NewRequest("OPTIONS", "/v1/swift-codes", nil)
+	req.Header.Set("Origin", "https://bank.example")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, "https://bank.example", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	req = httptest.NewRequest("OPTIONS", "/v1/swift-codes", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/healthcheck", nil)
+	rec := httptest.NewRecorder()
+	handlers.CreateRouter(config.Config{}).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Strict-Transport-Security"))
+
+	router := handlers.CreateRouter(config.Config{SecurityHeaders: config.SecurityHeaders{
+		Enabled:      true,
+		HSTSMaxAge:   600,
+		FrameOptions: "DENY",
+	}})
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, "max-age=600", rec.Header().Get("Strict-Transport-Security"))
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+}
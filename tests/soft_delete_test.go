@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const softDeleteCollectionName string = "test_soft_delete"
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, suffix := range []string{"", "_history", "_audit"} {
+		defer database.Collection(softDeleteCollectionName + suffix).Drop(context.Background())
+	}
+	ctx := context.Background()
+
+	swiftCode := services.SwiftCodes{
+		SwiftCode:       "SOFTCODEXXX",
+		CountryISO2Code: "AQ",
+		BankName:        "TestBank",
+		CountryName:     "ANTARCTICA",
+		IsHeadQuater:    true,
+	}
+	assert.NoError(t, swiftCode.InsertSwiftCode(ctx, swiftCode, softDeleteCollectionName))
+	assert.NoError(t, swiftCode.DeleteSwiftCode(ctx, "SOFTCODEXXX", "closed by the bank", softDeleteCollectionName))
+
+	assert.False(t, swiftCode.IsSwiftCodeInDatabase("SOFTCODEXXX", softDeleteCollectionName))
+	assert.True(t, swiftCode.IsSwiftCodeDeleted("SOFTCODEXXX", softDeleteCollectionName))
+	swiftCodes, err := swiftCode.FindSwiftCodes(services.LookupOptions{}, softDeleteCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(swiftCodes))
+	deleted, err := swiftCode.FindSwiftCode("SOFTCODEXXX", services.LookupOptions{IncludeDeleted: true}, softDeleteCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "closed by the bank", deleted.DeleteReason)
+	assert.NotNil(t, deleted.DeletedAt)
+
+	assert.NoError(t, swiftCode.RestoreSwiftCode(ctx, "SOFTCODEXXX", softDeleteCollectionName))
+	restored, err := swiftCode.GetSwiftCodeBySwiftCodeName("SOFTCODEXXX", softDeleteCollectionName)
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Empty(t, restored.DeleteReason)
+	//Check if app don't restore codes which aren't deleted
+	assert.Error(t, swiftCode.RestoreSwiftCode(ctx, "SOFTCODEXXX", softDeleteCollectionName))
+
+	assert.NoError(t, swiftCode.DeleteSwiftCode(ctx, "SOFTCODEXXX", "", softDeleteCollectionName))
+	purged, err := swiftCode.PurgeDeletedSwiftCodes(ctx, time.Now().Add(-time.Hour), softDeleteCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+	purged, err = swiftCode.PurgeDeletedSwiftCodes(ctx, time.Now().Add(time.Hour), softDeleteCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	assert.False(t, swiftCode.IsSwiftCodeDeleted("SOFTCODEXXX", softDeleteCollectionName))
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const syncCollectionName string = "test_sync"
+
+func TestReadDirectory(t *testing.T) {
+	file := "COUNTRY ISO2 CODE,SWIFT CODE,CODE TYPE,NAME,ADDRESS,TOWN NAME,COUNTRY NAME,TIME ZONE,VALID TO\n" +
+		"AL,AAISALTRXXX,BIC11,UNITED BANK OF ALBANIA SH.A,\"HYRJA 3, TIRANA\",TIRANA,ALBANIA,Europe/Tirane,2030-01-01\n" +
+		"AL,AAISALTR123,BIC11,UNITED BANK OF ALBANIA SH.A,HYRJA 4,TIRANA,ALBANIA,Europe/Tirane,\n"
+
+	swiftCodes, err := parser.ReadDirectory(strings.NewReader(file), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(swiftCodes))
+	assert.True(t, swiftCodes[0].IsHeadQuater)
+	assert.Equal(t, "HYRJA 3, TIRANA", swiftCodes[0].Address)
+	assert.Equal(t, 2030, swiftCodes[0].ValidTo.Year())
+	assert.False(t, swiftCodes[1].IsHeadQuater)
+	assert.Nil(t, swiftCodes[1].ValidTo)
+
+	//Check if app reports the line of an invalid row
+	_, err = parser.ReadDirectory(strings.NewReader("SWIFT CODE,VALID FROM\nAAISALTRXXX,soon\n"), "")
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestSync(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, suffix := range []string{"", "_history", "_audit", "_sync", "_sync_lock"} {
+		defer database.Collection(syncCollectionName + suffix).Drop(context.Background())
+	}
+	ctx := context.Background()
+
+	var swiftCode services.SwiftCodes
+	for _, code := range []string{"SYNCKEEPXXX", "SYNCCHNGXXX", "SYNCDROPXXX", "SYNCBACKXXX"} {
+		assert.NoError(t, swiftCode.InsertSwiftCode(ctx, services.SwiftCodes{
+			SwiftCode:       code,
+			CountryISO2Code: "AQ",
+			BankName:        "Old Name",
+			CountryName:     "ANTARCTICA",
+			IsHeadQuater:    true,
+		}, syncCollectionName))
+	}
+	assert.NoError(t, swiftCode.DeleteSwiftCode(ctx, "SYNCBACKXXX", "", syncCollectionName))
+
+	incoming := []services.SwiftCodes{
+		{SwiftCode: "SYNCKEEPXXX", CountryISO2Code: "AQ", BankName: "Old Name", CountryName: "ANTARCTICA", IsHeadQuater: true},
+		{SwiftCode: "SYNCCHNGXXX", CountryISO2Code: "AQ", BankName: "New Name", CountryName: "ANTARCTICA", IsHeadQuater: true},
+		{SwiftCode: "SYNCBACKXXX", CountryISO2Code: "AQ", BankName: "Old Name", CountryName: "ANTARCTICA", IsHeadQuater: true},
+		{SwiftCode: "SYNCNEWWXXX", CountryISO2Code: "AQ", BankName: "New Bank", CountryName: "ANTARCTICA", IsHeadQuater: true},
+	}
+	plan, err := swiftCode.PlanSync(incoming, syncCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, plan.Current)
+	assert.Equal(t, 1, plan.Unchanged)
+	assert.Equal(t, 2, len(plan.Additions))
+	assert.Equal(t, 1, len(plan.Changes))
+	assert.Equal(t, []string{"bankname"}, plan.Changes[0].Fields)
+	assert.Equal(t, 1, len(plan.Removals))
+	assert.Equal(t, "SYNCDROPXXX", plan.Removals[0].SwiftCode)
+
+	//Check if app refuses to remove too many codes
+	err = swiftCode.ApplySync(ctx, plan, 0.1, syncCollectionName)
+	assert.Error(t, err)
+	_, err = swiftCode.GetSwiftCodeBySwiftCodeName("SYNCDROPXXX", syncCollectionName)
+	assert.NoError(t, err)
+
+	assert.NoError(t, swiftCode.ApplySync(ctx, plan, 0.5, syncCollectionName))
+
+	//Check if app keeps the indexes of the directory it replaces
+	_, err = swiftCode.FindSwiftCodesNear(ctx, 52.23, 21.01, 10, services.LookupOptions{}, syncCollectionName)
+	assert.NoError(t, err)
+
+	changed, err := swiftCode.GetSwiftCodeBySwiftCodeName("SYNCCHNGXXX", syncCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, "New Name", changed.BankName)
+	assert.Equal(t, 2, changed.Version)
+	_, err = swiftCode.GetSwiftCodeBySwiftCodeName("SYNCNEWWXXX", syncCollectionName)
+	assert.NoError(t, err)
+	_, err = swiftCode.GetSwiftCodeBySwiftCodeName("SYNCBACKXXX", syncCollectionName)
+	assert.NoError(t, err)
+	assert.True(t, swiftCode.IsSwiftCodeDeleted("SYNCDROPXXX", syncCollectionName))
+
+	var audit services.AuditEntries
+	entries, err := audit.GetAuditEntries(services.AuditFilter{SwiftCode: "SYNCCHNGXXX"}, syncCollectionName)
+	assert.NoError(t, err)
+	assert.Equal(t, services.OperationSync, entries[0].Operation)
+	assert.Equal(t, "Old Name", entries[0].Before.BankName)
+
+	//Check if app refuses a plan computed before a later change
+	plan, err = swiftCode.PlanSync(incoming[:1], syncCollectionName)
+	assert.NoError(t, err)
+	assert.NoError(t, swiftCode.DeleteSwiftCode(ctx, "SYNCNEWWXXX", "", syncCollectionName))
+	assert.Error(t, swiftCode.ApplySync(ctx, plan, 1, syncCollectionName))
+
+	//Check if app refuses a plan computed before a change of derived data, which keeps the version
+	plan, err = swiftCode.PlanSync(incoming[:1], syncCollectionName)
+	assert.NoError(t, err)
+	_, err = database.Collection(syncCollectionName).UpdateOne(ctx, bson.M{"_swiftcode": "SYNCKEEPXXX"},
+		bson.M{"$set": bson.M{"_location": services.GeoPoints{Type: "Point", Coordinates: [2]float64{0, -90}}}})
+	assert.NoError(t, err)
+	assert.Error(t, swiftCode.ApplySync(ctx, plan, 1, syncCollectionName))
+
+	//Check if app refuses writes while a sync is applied
+	locks := database.Collection(syncCollectionName + "_sync_lock")
+	_, err = locks.InsertOne(ctx, services.SyncLocks{ID: "sync", Since: time.Now()})
+	assert.NoError(t, err)
+	err = swiftCode.UpdateSwiftCode(ctx, "SYNCKEEPXXX", services.SwiftCodes{BankName: "Racing Bank"}, syncCollectionName)
+	assert.ErrorIs(t, err, services.ErrSyncInProgress)
+	_, err = swiftCode.LocateSwiftCodes(ctx, syncCollectionName)
+	assert.ErrorIs(t, err, services.ErrSyncInProgress)
+	_, err = locks.DeleteOne(ctx, bson.M{"_id": "sync"})
+	assert.NoError(t, err)
+}
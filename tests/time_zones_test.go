@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTimeZone(t *testing.T) {
+	//Check if app defaults the time zone of a country with a single one
+	timeZone, err := services.ValidateTimeZone("", "PL")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Warsaw", timeZone)
+
+	//Check if app leaves the time zone of a country with several ones empty
+	timeZone, err = services.ValidateTimeZone("", "US")
+	assert.NoError(t, err)
+	assert.Equal(t, "", timeZone)
+
+	timeZone, err = services.ValidateTimeZone("America/Chicago", "US")
+	assert.NoError(t, err)
+	assert.Equal(t, "America/Chicago", timeZone)
+
+	//Check if app refuses time zones missing from the IANA database
+	_, err = services.ValidateTimeZone("Test/Zone", "PL")
+	assert.Error(t, err)
+	_, err = services.ValidateTimeZone("Local", "PL")
+	assert.Error(t, err)
+}
+
+func TestLocalTimeAt(t *testing.T) {
+	swiftCode := services.SwiftCodes{SwiftCode: "BREXPLPWXXX", TimeZone: "Europe/Warsaw"}
+
+	//Check if app reports summer time and business hours
+	localTime, err := services.LocalTimeAt(swiftCode, time.Date(2025, 7, 1, 8, 30, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, localTime.LocalTime.Hour())
+	assert.Equal(t, "CEST", localTime.Abbreviation)
+	assert.Equal(t, "+02:00", localTime.UTCOffset)
+	assert.Equal(t, 7200, localTime.UTCOffsetSeconds)
+	assert.True(t, localTime.BusinessDay)
+	assert.True(t, localTime.BusinessHours)
+
+	//Check if app reports winter time outside business hours
+	localTime, err = services.LocalTimeAt(swiftCode, time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, "+01:00", localTime.UTCOffset)
+	assert.False(t, localTime.BusinessDay)
+	assert.False(t, localTime.BusinessHours)
+
+	_, err = services.LocalTimeAt(services.SwiftCodes{SwiftCode: "NOZONEXXXXX"}, time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-mongo-app/config"
+	"github.com/go-mongo-app/handlers"
+	"github.com/go-mongo-app/server"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// issueTestCertificate signs a certificate with parent, or self-signs it
+// when parent is nil.
+func issueTestCertificate(t *testing.T, commonName string, isCA bool, parent *testCertificate) testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{"Test"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return testCertificate{cert: cert, key: key, der: der}
+}
+
+func (c testCertificate) write(t *testing.T, dir string, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return certFile, keyFile
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	first := issueTestCertificate(t, "first", false, nil)
+	certFile, keyFile := first.write(t, dir, "server")
+
+	reloader, err := server.NewCertReloader(certFile, keyFile, "")
+	assert.NoError(t, err)
+	reloaded, err := reloader.ReloadIfChanged()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	second := issueTestCertificate(t, "second", false, nil)
+	second.write(t, dir, "server")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	reloaded, err = reloader.ReloadIfChanged()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+
+	cert, err := reloader.GetCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, second.der, cert.Certificate[0])
+}
+
+func TestMutualTLSPrincipal(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCertificate(t, "Test CA", true, nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	serverCertFile, serverKeyFile := issueTestCertificate(t, "localhost", false, &ca).write(t, dir, "server")
+	client := issueTestCertificate(t, "reporting-service", false, &ca)
+
+	reloader, err := server.NewCertReloader(serverCertFile, serverKeyFile, caFile)
+	assert.NoError(t, err)
+	srv := httptest.NewUnstartedServer(handlers.CreateRouter(config.Config{Auth: config.Auth{
+		Enabled:         true,
+		MTLSRoleMapping: map[string]string{"reporting-service": "reader"},
+	}}))
+	srv.TLS = reloader.TLSConfig(tls.RequireAndVerifyClientCert)
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs: roots,
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{client.der},
+			PrivateKey:  client.key,
+		}},
+	}}}
+
+	//Client certificate is mapped to the reader role, so it can't delete
+	req, err := http.NewRequest("DELETE", srv.URL+"/v1/swift-codes/TESTCODEXXX", nil)
+	assert.NoError(t, err)
+	resp, err := httpClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	//Connections without a client certificate are refused
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	_, err = anonymous.Get(srv.URL + "/v1/healthcheck")
+	assert.Error(t, err)
+}
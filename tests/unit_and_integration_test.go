@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-mongo-app/config"
 	"github.com/go-mongo-app/db"
 	"github.com/go-mongo-app/handlers"
 	"github.com/go-mongo-app/services"
@@ -38,7 +39,7 @@ func TestMain(m *testing.M) {
 	services.New(testClient)
 
 	log.Println("Server running in port", 8000)
-	go http.ListenAndServe(":8000", handlers.CreateRouter())
+	go http.ListenAndServe(":8000", handlers.CreateRouter(config.Config{}))
 
 	testCollection = testClient.Database("swift_codes_db").Collection(collectionName)
 
@@ -63,16 +64,16 @@ func TestMongoConnection(t *testing.T) {
 func TestInsertSwiftCode(t *testing.T) {
 	swiftCode := services.SwiftCodes{
 		SwiftCode:       "TESTCODEXXX",
-		CountryISO2Code: "TT",
+		CountryISO2Code: "AQ",
 		CodeType:        "BIC11",
 		BankName:        "TestBank",
 		Address:         "Test address",
 		TownName:        "Test Town",
-		CountryName:     "Test Country",
-		TimeZone:        "Test/Zone",
+		CountryName:     "ANTARCTICA",
+		TimeZone:        "Antarctica/Troll",
 		IsHeadQuater:    true,
 	}
-	err := swiftCode.InsertSwiftCode(swiftCode, collectionName)
+	err := swiftCode.InsertSwiftCode(context.Background(), swiftCode, collectionName)
 	assert.NoError(t, err)
 	ctx := context.TODO()
 	var foundCode services.SwiftCodes
@@ -85,16 +86,16 @@ func TestInsertInvalidSwiftCodes(t *testing.T) {
 	//Check if app don't add swiftCode with SwiftCode field not equal 11
 	swiftCode := services.SwiftCodes{
 		SwiftCode:       "TESTCODE",
-		CountryISO2Code: "TT",
+		CountryISO2Code: "AQ",
 		CodeType:        "BIC11",
 		BankName:        "TestBank",
 		Address:         "Test address",
 		TownName:        "Test Town",
-		CountryName:     "Test Country",
-		TimeZone:        "Test/Zone",
+		CountryName:     "ANTARCTICA",
+		TimeZone:        "Antarctica/Troll",
 		IsHeadQuater:    true,
 	}
-	err := swiftCode.InsertSwiftCode(swiftCode, collectionName)
+	err := swiftCode.InsertSwiftCode(context.Background(), swiftCode, collectionName)
 	assert.Error(t, err)
 	//Check if app don't add swiftCode with CountryISO2Code field not equal 2
 	swiftCode = services.SwiftCodes{
@@ -104,25 +105,25 @@ func TestInsertInvalidSwiftCodes(t *testing.T) {
 		BankName:        "TestBank",
 		Address:         "Test address",
 		TownName:        "Test Town",
-		CountryName:     "Test Country",
-		TimeZone:        "Test/Zone",
+		CountryName:     "ANTARCTICA",
+		TimeZone:        "Antarctica/Troll",
 		IsHeadQuater:    true,
 	}
-	err = swiftCode.InsertSwiftCode(swiftCode, collectionName)
+	err = swiftCode.InsertSwiftCode(context.Background(), swiftCode, collectionName)
 	assert.Error(t, err)
 	//Check if app don't add swiftCode with same SwiftCode field
 	swiftCode = services.SwiftCodes{
 		SwiftCode:       "TESTCODEXXX",
-		CountryISO2Code: "TT",
+		CountryISO2Code: "AQ",
 		CodeType:        "BIC11",
 		BankName:        "TestBank",
 		Address:         "Test address",
 		TownName:        "Test Town",
-		CountryName:     "Test Country",
-		TimeZone:        "Test/Zone",
+		CountryName:     "ANTARCTICA",
+		TimeZone:        "Antarctica/Troll",
 		IsHeadQuater:    true,
 	}
-	err = swiftCode.InsertSwiftCode(swiftCode, collectionName)
+	err = swiftCode.InsertSwiftCode(context.Background(), swiftCode, collectionName)
 	assert.Error(t, err)
 }
 
@@ -137,17 +138,17 @@ func TestGetAllSwiftCodes(t *testing.T) {
 
 	swiftCode = services.SwiftCodes{
 		SwiftCode:       "TESTCODE123",
-		CountryISO2Code: "TT",
+		CountryISO2Code: "AQ",
 		CodeType:        "BIC11",
 		BankName:        "TestBank",
 		Address:         "Test address",
 		TownName:        "Test Town",
-		CountryName:     "Test Country",
-		TimeZone:        "Test/Zone",
+		CountryName:     "ANTARCTICA",
+		TimeZone:        "Antarctica/Troll",
 		IsHeadQuater:    false,
 	}
 
-	swiftCode.InsertSwiftCode(swiftCode, collectionName)
+	swiftCode.InsertSwiftCode(context.Background(), swiftCode, collectionName)
 	numOfSwiftCodes += 1
 	swiftCodes, err = swiftCode.GetAllSwiftCodes(collectionName)
 	assert.NoError(t, err)
@@ -161,7 +162,7 @@ func TestGetSwiftCodesByName(t *testing.T) {
 	swiftCode, err := swiftCode.GetSwiftCodeBySwiftCodeName("TESTCODE123", collectionName)
 	assert.NoError(t, err)
 	assert.Equal(t, "TESTCODE123", swiftCode.SwiftCode)
-	assert.Equal(t, "TT", swiftCode.CountryISO2Code)
+	assert.Equal(t, "AQ", swiftCode.CountryISO2Code)
 	swiftCode, err = swiftCode.GetSwiftCodeBySwiftCodeName("TESTCODE456", collectionName)
 	assert.Error(t, err)
 
@@ -177,7 +178,7 @@ func TestGetHeadquater(t *testing.T) {
 	swiftCode, err := swiftCode.GetHeadquater("TESTCODE", collectionName)
 	assert.NoError(t, err)
 	assert.Equal(t, "TESTCODEXXX", swiftCode.SwiftCode)
-	assert.Equal(t, "TT", swiftCode.CountryISO2Code)
+	assert.Equal(t, "AQ", swiftCode.CountryISO2Code)
 
 	swiftCode, err = swiftCode.GetHeadquater("BADCODE1", collectionName)
 	assert.Error(t, err)
@@ -193,17 +194,17 @@ func TestGetAllBranches(t *testing.T) {
 
 	swiftCode = services.SwiftCodes{
 		SwiftCode:       "TESTOTHRXXX",
-		CountryISO2Code: "TT",
+		CountryISO2Code: "AQ",
 		CodeType:        "BIC11",
 		BankName:        "TestBank",
 		Address:         "Test address",
 		TownName:        "Test Town",
-		CountryName:     "Test Country",
-		TimeZone:        "Test/Zone",
+		CountryName:     "ANTARCTICA",
+		TimeZone:        "Antarctica/Troll",
 		IsHeadQuater:    true,
 	}
 
-	swiftCode.InsertSwiftCode(swiftCode, collectionName)
+	swiftCode.InsertSwiftCode(context.Background(), swiftCode, collectionName)
 	prefix = "TESTOTHR"
 	swiftCodes, err = swiftCode.GetAllBranchersWithPrefix(prefix, collectionName)
 	assert.NoError(t, err)
@@ -213,7 +214,7 @@ func TestGetAllBranches(t *testing.T) {
 
 func TestGetAllSwiftCoidesByISOCode(t *testing.T) {
 	var swiftCode services.SwiftCodes
-	isoCode := "TT"
+	isoCode := "AQ"
 	swiftCodes, err := swiftCode.GetAllSwiftCoidesByISOCode(isoCode, collectionName)
 	assert.NoError(t, err)
 	expectedNumOfSwiftCodes := 3
@@ -227,9 +228,9 @@ func TestGetAllSwiftCoidesByISOCode(t *testing.T) {
 
 func TestDeleteSwiftCodes(t *testing.T) {
 	var swiftCode services.SwiftCodes
-	err := swiftCode.DeleteSwiftCode("TESTOTHRXXX", collectionName)
+	err := swiftCode.DeleteSwiftCode(context.Background(), "TESTOTHRXXX", "", collectionName)
 	assert.NoError(t, err)
-	err = swiftCode.DeleteSwiftCode("TESTOTHRXXX", collectionName)
+	err = swiftCode.DeleteSwiftCode(context.Background(), "TESTOTHRXXX", "", collectionName)
 	assert.Error(t, err)
 }
 
@@ -240,7 +241,7 @@ func TestCreateSwiftCodeByPOSTRequest(t *testing.T) {
     "Address": "string",
     "BankName": "string",
     "CountryISO2Code": "st",
-    "CountryName": "string",
+    "CountryName": "Sao Tome and Principe",
     "IsHeadquarter": true,
     "SwiftCode": "StringtoXXX"
 	}`)
@@ -273,7 +274,7 @@ func TestCreateSwiftCodeByPOSTRequest(t *testing.T) {
 		"Address": "string",
 		"BankName": "string",
 		"CountryISO2Code": "st",
-		"CountryName": "string",
+		"CountryName": "Sao Tome and Principe",
 		"IsHeadquarter": false,
 		"SwiftCode": "Stringt"
 		}`)
@@ -295,7 +296,7 @@ func TestCreateSwiftCodeByPOSTRequest(t *testing.T) {
 		"Address": "string",
 		"BankName": "string",
 		"CountryISO2Code": "ddd",
-		"CountryName": "string",
+		"CountryName": "Sao Tome and Principe",
 		"IsHeadquarter": false,
 		"SwiftCode": "Stringto123"
 		}`)
@@ -317,7 +318,7 @@ func TestCreateSwiftCodeByPOSTRequest(t *testing.T) {
 		"Address": "string",
 		"BankName": "string",
 		"CountryISO2Code": "st",
-		"CountryName": "string",
+		"CountryName": "Sao Tome and Principe",
 		"IsHeadquarter": false,
 		"SwiftCode": "Stringto123"
 		}`)
@@ -339,7 +340,7 @@ func TestCreateSwiftCodeByPOSTRequest(t *testing.T) {
 		"Address": "string",
 		"BankName": "string",
 		"CountryISO2Code": "st",
-		"CountryName": "string",
+		"CountryName": "Sao Tome and Principe",
 		"IsHeadquarter": false,
 		"SwiftCode": "NewBranch11"
 		}`)
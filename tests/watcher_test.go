@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-mongo-app/config"
+	"github.com/go-mongo-app/parser"
+	"github.com/go-mongo-app/services"
+	"github.com/stretchr/testify/assert"
+)
+
+const watchedFilesCollectionName string = "test_watched_files"
+
+func TestWatcher(t *testing.T) {
+	database := testClient.Database("swift_codes_db")
+	for _, name := range []string{
+		watchedFilesCollectionName, importJobsCollectionName, importJobsCollectionName + "_payloads.files", importJobsCollectionName + "_payloads.chunks",
+		importedCollectionName, importedCollectionName + "_history", importedCollectionName + "_audit",
+	} {
+		defer database.Collection(name).Drop(context.Background())
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	watcher := parser.Watcher{
+		Dir:                 dir,
+		CollectionName:      importedCollectionName,
+		JobsCollectionName:  importJobsCollectionName,
+		FilesCollectionName: watchedFilesCollectionName,
+	}
+	file := []byte("SWIFT CODE,COUNTRY ISO2 CODE,NAME,COUNTRY NAME\nWATCHBNKXXX,AQ,WATCHED BANK,ANTARCTICA\n")
+	listDir := func(sub string) []string {
+		entries, _ := os.ReadDir(filepath.Join(dir, sub))
+		var names []string
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				names = append(names, entry.Name())
+			}
+		}
+		return names
+	}
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "directory.csv"), file, 0o644))
+	assert.NoError(t, watcher.Scan(ctx))
+
+	var swiftCode services.SwiftCodes
+	_, err := swiftCode.GetSwiftCodeBySwiftCodeName("WATCHBNKXXX", importedCollectionName)
+	assert.NoError(t, err)
+	assert.Empty(t, listDir(""))
+	assert.Equal(t, 1, len(listDir("processed")))
+
+	//Check if app skips content it already imported and moves broken files aside
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "again.csv"), file, 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.txt"), []byte("not a directory file"), 0o644))
+	assert.NoError(t, watcher.Scan(ctx))
+	assert.Empty(t, listDir(""))
+	assert.Equal(t, 2, len(listDir("processed")))
+	assert.Equal(t, 1, len(listDir("failed")))
+
+	var watchedFile services.WatchedFiles
+	watchedFiles, err := watchedFile.GetWatchedFiles(10, watchedFilesCollectionName)
+	assert.NoError(t, err)
+	statuses := map[string]string{}
+	for _, watched := range watchedFiles {
+		statuses[watched.FileName] = watched.Status
+	}
+	assert.Equal(t, map[string]string{
+		"directory.csv": services.WatchedImported,
+		"again.csv":     services.WatchedDuplicate,
+		"broken.txt":    services.WatchedFailed,
+	}, statuses)
+}
+
+func TestWatchIntervalConfig(t *testing.T) {
+	t.Setenv("WATCH_DIR", t.TempDir())
+
+	//Check if app refuses to watch without a positive interval
+	for _, interval := range []string{"0s", "-1m"} {
+		t.Setenv("WATCH_INTERVAL", interval)
+		_, err := config.Load(true)
+		assert.Error(t, err)
+	}
+
+	t.Setenv("WATCH_INTERVAL", "1m")
+	cfg, err := config.Load(true)
+	assert.NoError(t, err)
+	assert.Equal(t, "1m0s", cfg.Watch.Interval.String())
+}